@@ -0,0 +1,106 @@
+package database
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"myarea-backend/models"
+)
+
+// loadCategorySlugs returns every category slug in the table
+func loadCategorySlugs() ([]string, error) {
+	var slugs []string
+	err := DB.Model(&models.Category{}).Pluck("slug", &slugs).Error
+	return slugs, err
+}
+
+// CategoryDescendantSlugs returns the slug plus the slugs of all its subcategories
+func CategoryDescendantSlugs(slug string) ([]string, error) {
+	var categories []models.Category
+	if err := DB.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	children := make(map[string][]models.Category)
+	var root *models.Category
+	for i := range categories {
+		if categories[i].ParentID != nil {
+			parent := categories[i].ParentID.String()
+			children[parent] = append(children[parent], categories[i])
+		}
+		if categories[i].Slug == slug {
+			root = &categories[i]
+		}
+	}
+
+	slugs := []string{slug}
+	if root == nil {
+		return slugs, nil
+	}
+	queue := []models.Category{*root}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range children[current.ID.String()] {
+			slugs = append(slugs, child.Slug)
+			queue = append(queue, child)
+		}
+	}
+	return slugs, nil
+}
+
+// SeedCategories adds the built-in categories if the categories table is empty
+func SeedCategories() {
+	var count int64
+	DB.Model(&models.Category{}).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	categories := []models.Category{
+		{Slug: models.CategoryRestaurant, DisplayName: "Restaurant", Icon: "utensils", Color: "#E53E3E"},
+		{Slug: models.CategoryCafe, DisplayName: "Cafe", Icon: "coffee", Color: "#D69E2E"},
+		{Slug: models.CategoryBar, DisplayName: "Bar", Icon: "wine", Color: "#9F7AEA"},
+		{Slug: models.CategoryShopping, DisplayName: "Shopping", Icon: "shopping-bag", Color: "#3182CE"},
+		{Slug: models.CategoryPark, DisplayName: "Park", Icon: "trees", Color: "#38A169"},
+		{Slug: models.CategoryHike, DisplayName: "Hike", Icon: "mountain", Color: "#319795"},
+		{Slug: models.CategoryMuseum, DisplayName: "Museum", Icon: "palette", Color: "#805AD5"},
+		{Slug: models.CategoryEntertainment, DisplayName: "Entertainment", Icon: "music", Color: "#E53E3E"},
+		{Slug: models.CategoryBeach, DisplayName: "Beach", Icon: "waves", Color: "#0BC5EA"},
+		{Slug: models.CategoryViewpoint, DisplayName: "Viewpoint", Icon: "camera", Color: "#3182CE"},
+		{Slug: models.CategoryOther, DisplayName: "Other", Icon: "map-pin", Color: "#718096"},
+	}
+
+	for i := range categories {
+		categories[i].SortOrder = i * 10
+		if err := DB.Create(&categories[i]).Error; err != nil {
+			log.Printf("Failed to seed category %s: %v", categories[i].Slug, err)
+		}
+	}
+
+	models.InvalidateCategoryCache()
+	log.Printf("✅ Seeded %d categories", len(categories))
+}
+
+// PromoteAdmins grants the admin role to the users listed in ADMIN_EMAILS
+func PromoteAdmins() {
+	var emails []string
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	if len(emails) == 0 {
+		return
+	}
+
+	result := DB.Model(&models.User{}).Where("email IN ?", emails).Update("role", models.RoleAdmin)
+	if result.Error != nil {
+		log.Printf("Failed to promote admins: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("✅ Granted admin role to %d users", result.RowsAffected)
+	}
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"myarea-backend/geo"
+	"myarea-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// FindCityForPoint returns the city whose boundary contains the point, or nil.
+// Where boundaries overlap the smallest city wins, then the lowest ID.
+func FindCityForPoint(lat, lng float64) (*models.City, error) {
+	var candidates []models.City
+	err := DB.Where("bounds_min_latitude <= ? AND bounds_max_latitude >= ? AND bounds_min_longitude <= ? AND bounds_max_longitude >= ?",
+		lat, lat, lng, lng).
+		Order("id").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+	return smallestCityContaining(candidates, lat, lng), nil
+}
+
+// smallestCityContaining picks the city with the smallest boundary containing
+// the point, keeping the earliest on ties
+func smallestCityContaining(cities []models.City, lat, lng float64) *models.City {
+	var best *models.City
+	bestArea := 0.0
+	for i := range cities {
+		if !cities[i].Boundary.Contains(lat, lng) {
+			continue
+		}
+		if area := cities[i].Boundary.Area(); best == nil || area < bestArea {
+			best, bestArea = &cities[i], area
+		}
+	}
+	return best
+}
+
+// FindCityByName resolves a slug, name or alias to a city, or nil if none match
+func FindCityByName(name string) (*models.City, error) {
+	normalized := models.NormalizeCityName(name)
+	if normalized == "" {
+		return nil, nil
+	}
+	slug := strings.ReplaceAll(normalized, " ", "-")
+
+	var city models.City
+	err := DB.Where("slug = ? OR LOWER(name) = ? OR ? = ANY(aliases)", slug, normalized, normalized).
+		First(&city).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &city, nil
+}
+
+// DefaultCity returns the city flagged as default, or nil if there is none
+func DefaultCity() (*models.City, error) {
+	var city models.City
+	err := DB.Where("is_default = ?", true).First(&city).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &city, nil
+}
+
+// LinkLocationsToCities assigns a city to every location that has none yet
+func LinkLocationsToCities() {
+	var locations []models.Location
+	if err := DB.Where("city_id IS NULL").Find(&locations).Error; err != nil {
+		log.Printf("Failed to load unlinked locations: %v", err)
+		return
+	}
+
+	linked := 0
+	for _, location := range locations {
+		city, err := FindCityForPoint(location.Latitude, location.Longitude)
+		if err != nil || city == nil {
+			continue
+		}
+		err = DB.Model(&models.Location{}).Where("id = ?", location.ID).
+			Updates(map[string]interface{}{"city_id": city.ID, "city": city.Name}).Error
+		if err == nil {
+			linked++
+		}
+	}
+	if linked > 0 {
+		log.Printf("✅ Linked %d locations to cities", linked)
+	}
+}
+
+// SeedCities adds the Bay Area cities if the cities table is empty
+func SeedCities() {
+	var count int64
+	DB.Model(&models.City{}).Count(&count)
+	if count > 0 {
+		fixSeededOaklandBoundary()
+		return
+	}
+
+	cities := []models.City{
+		{
+			Slug:      "san-francisco",
+			Name:      "San Francisco",
+			Region:    "Bay Area",
+			Country:   "US",
+			Aliases:   []string{"SF", "San Fran", "Frisco", "San Francisco, CA"},
+			Boundary:  rect(37.7080, -122.5150, 37.8324, -122.3570),
+			Viewport:  models.Viewport{Latitude: 37.7749, Longitude: -122.4194, Zoom: 12},
+			IsDefault: true,
+			TimeZone:  "America/Los_Angeles",
+		},
+		{
+			Slug:     "oakland",
+			Name:     "Oakland",
+			Region:   "Bay Area",
+			Country:  "US",
+			Aliases:  []string{"Oakland, CA", "The Town"},
+			Boundary: rect(37.6990, -122.3420, 37.8450, -122.1150),
+			Viewport: models.Viewport{Latitude: 37.8044, Longitude: -122.2712, Zoom: 12},
+			TimeZone: "America/Los_Angeles",
+		},
+		{
+			Slug:     "berkeley",
+			Name:     "Berkeley",
+			Region:   "Bay Area",
+			Country:  "US",
+			Aliases:  []string{"Berkeley, CA"},
+			Boundary: rect(37.8450, -122.3250, 37.9060, -122.2340),
+			Viewport: models.Viewport{Latitude: 37.8715, Longitude: -122.2730, Zoom: 13},
+			TimeZone: "America/Los_Angeles",
+		},
+		{
+			Slug:     "mill-valley",
+			Name:     "Mill Valley",
+			Region:   "Bay Area",
+			Country:  "US",
+			Aliases:  []string{"Mill Valley, CA"},
+			Boundary: rect(37.8700, -122.6000, 37.9300, -122.5000),
+			Viewport: models.Viewport{Latitude: 37.9060, Longitude: -122.5450, Zoom: 13},
+			TimeZone: "America/Los_Angeles",
+		},
+		{
+			Slug:     "san-jose",
+			Name:     "San Jose",
+			Region:   "Bay Area",
+			Country:  "US",
+			Aliases:  []string{"SJ", "San José", "San Jose, CA"},
+			Boundary: rect(37.1240, -122.0460, 37.4690, -121.5890),
+			Viewport: models.Viewport{Latitude: 37.3382, Longitude: -121.8863, Zoom: 11},
+			TimeZone: "America/Los_Angeles",
+		},
+	}
+
+	for _, city := range cities {
+		if err := DB.Create(&city).Error; err != nil {
+			log.Printf("Failed to seed city %s: %v", city.Name, err)
+		}
+	}
+
+	log.Printf("✅ Seeded %d cities", len(cities))
+}
+
+// fixSeededOaklandBoundary moves Oakland's northern edge from earlier seeds,
+// which overlapped Berkeley, down to where Berkeley starts
+func fixSeededOaklandBoundary() {
+	var city models.City
+	if err := DB.Where("slug = ? AND bounds_max_latitude = ?", "oakland", 37.8850).First(&city).Error; err != nil {
+		return
+	}
+	city.Boundary = rect(37.6990, -122.3420, 37.8450, -122.1150)
+	if err := DB.Save(&city).Error; err != nil {
+		log.Printf("Failed to update Oakland boundary: %v", err)
+	}
+}
+
+// rect builds a rectangular boundary from two corners
+func rect(minLat, minLng, maxLat, maxLng float64) geo.Polygon {
+	return geo.Polygon{
+		{minLng, minLat},
+		{maxLng, minLat},
+		{maxLng, maxLat},
+		{minLng, maxLat},
+		{minLng, minLat},
+	}
+}
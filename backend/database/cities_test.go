@@ -0,0 +1,40 @@
+package database
+
+import (
+	"testing"
+
+	"myarea-backend/models"
+)
+
+func TestSmallestCityContaining(t *testing.T) {
+	region := models.City{Slug: "east-bay", Boundary: rect(37.6, -122.4, 38.0, -122.0)}
+	oakland := models.City{Slug: "oakland", Boundary: rect(37.6990, -122.3420, 37.8450, -122.1150)}
+	berkeley := models.City{Slug: "berkeley", Boundary: rect(37.8450, -122.3250, 37.9060, -122.2340)}
+	cities := []models.City{region, oakland, berkeley}
+
+	tests := []struct {
+		lat, lng float64
+		want     string
+	}{
+		{37.8044, -122.2712, "oakland"},
+		{37.8715, -122.2730, "berkeley"},
+		{37.9500, -122.1000, "east-bay"},
+		{37.5000, -122.2000, ""},
+	}
+	for _, tt := range tests {
+		got := smallestCityContaining(cities, tt.lat, tt.lng)
+		slug := ""
+		if got != nil {
+			slug = got.Slug
+		}
+		if slug != tt.want {
+			t.Errorf("(%v, %v) = %q, want %q", tt.lat, tt.lng, slug, tt.want)
+		}
+	}
+
+	// Equal boundaries keep the first, which the query orders by ID
+	twin := models.City{Slug: "twin", Boundary: oakland.Boundary}
+	if got := smallestCityContaining([]models.City{oakland, twin}, 37.8044, -122.2712); got.Slug != "oakland" {
+		t.Errorf("tie picked %q", got.Slug)
+	}
+}
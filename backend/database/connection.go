@@ -24,6 +24,8 @@ func Connect() {
 	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 		PrepareStmt: false,
+		// Report unique violations as gorm.ErrDuplicatedKey
+		TranslateError: true,
 	})
 
 	if err != nil {
@@ -35,10 +37,17 @@ func Connect() {
 
 // Migrate runs database migrations
 func Migrate() {
-	err := DB.AutoMigrate(&models.User{}, &models.Location{}, &models.Guide{})
+	err := DB.AutoMigrate(&models.User{}, &models.City{}, &models.Category{}, &models.TagAlias{}, &models.Friendship{}, &models.Location{}, &models.LocationRevision{}, &models.LocationAlias{}, &models.ImportJob{}, &models.Image{}, &models.LocationPhoto{}, &models.Review{}, &models.SavedList{}, &models.SavedLocation{}, &models.Guide{}, &models.GuideItem{}, &models.GuideShare{}, &models.GuideComment{}, &models.GuideCollaborator{}, &models.EmailVerification{})
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
+	if err := migrateGuideLocationIDs(); err != nil {
+		log.Fatalf("Failed to migrate guide locations: %v", err)
+	}
+	if err := migrateSavedLocationIndexes(); err != nil {
+		log.Fatalf("Failed to migrate saved location indexes: %v", err)
+	}
+	models.CategoryLoader = loadCategorySlugs
 	log.Println("✅ Database migrations completed")
 }
 
@@ -0,0 +1,26 @@
+package database
+
+import (
+	"myarea-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshGuideForkCount recomputes how many guides were forked from a guide.
+// Like ratings, it doesn't touch updated_at or the guide's version.
+func RefreshGuideForkCount(tx *gorm.DB, guideID uuid.UUID) error {
+	return tx.Exec(`
+		UPDATE guides
+		SET fork_count = (SELECT COUNT(*) FROM guides AS forks WHERE forks.forked_from_id = ?)
+		WHERE id = ?`, guideID, guideID).Error
+}
+
+// moveLocationCopies points copies of source at target. A copy being merged
+// into its own source loses the link rather than pointing at itself.
+func moveLocationCopies(tx *gorm.DB, sourceID, targetID uuid.UUID) error {
+	if err := tx.Model(&models.Location{}).Unscoped().Where("copied_from_id = ?", sourceID).UpdateColumn("copied_from_id", targetID).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Location{}).Unscoped().Where("id = ? AND copied_from_id = ?", targetID, targetID).UpdateColumn("copied_from_id", nil).Error
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"myarea-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// migrateGuideLocationIDs moves the old guides.location_ids array into
+// guide_items and drops the column. Locations that no longer exist are skipped.
+func migrateGuideLocationIDs() error {
+	if !DB.Migrator().HasColumn(&models.Guide{}, "location_ids") {
+		return nil
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`
+			INSERT INTO guide_items (guide_id, location_id, position, created_at, updated_at)
+			SELECT g.id, u.id, MIN(u.i) - 1, NOW(), NOW()
+			FROM guides g, unnest(g.location_ids) WITH ORDINALITY AS u(id, i)
+			WHERE EXISTS (SELECT 1 FROM locations l WHERE l.id = u.id)
+			GROUP BY g.id, u.id
+			ON CONFLICT DO NOTHING`)
+		if result.Error != nil {
+			return result.Error
+		}
+		if err := compactGuideItems(tx, nil); err != nil {
+			return err
+		}
+		if err := tx.Migrator().DropColumn(&models.Guide{}, "location_ids"); err != nil {
+			return err
+		}
+		log.Printf("✅ Moved %d guide stops into guide_items", result.RowsAffected)
+		return nil
+	})
+}
+
+// CompactGuideItems renumbers a guide's items to consecutive positions from 0,
+// keeping their order
+func CompactGuideItems(tx *gorm.DB, guideID uuid.UUID) error {
+	return compactGuideItems(tx, []uuid.UUID{guideID})
+}
+
+// compactGuideItems renumbers the items of the given guides, or of every guide when guideIDs is nil
+func compactGuideItems(tx *gorm.DB, guideIDs []uuid.UUID) error {
+	filter := ""
+	var args []interface{}
+	if guideIDs != nil {
+		if len(guideIDs) == 0 {
+			return nil
+		}
+		filter = "WHERE guide_id IN ?"
+		args = append(args, guideIDs)
+	}
+	return tx.Exec(`
+		UPDATE guide_items g
+		SET position = r.rn - 1
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY guide_id ORDER BY position, created_at) AS rn
+			FROM guide_items `+filter+`
+		) r
+		WHERE g.id = r.id AND g.position <> r.rn - 1`, args...).Error
+}
+
+// BumpGuidesListing increments the version of every guide that lists one of
+// the locations, for changes to their stops made outside an edit of the guide
+// itself. The update also locks those guides until tx ends, so it should run
+// before the stops change.
+func BumpGuidesListing(tx *gorm.DB, locationIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var guideIDs []uuid.UUID
+	err := tx.Model(&models.GuideItem{}).Distinct("guide_id").Where("location_id IN ?", locationIDs).Pluck("guide_id", &guideIDs).Error
+	if err != nil || len(guideIDs) == 0 {
+		return nil, err
+	}
+	err = tx.Model(&models.Guide{}).Where("id IN ?", guideIDs).UpdateColumns(map[string]interface{}{
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": time.Now(),
+	}).Error
+	return guideIDs, err
+}
+
+// moveGuideItems points guide items at target instead of source. Guides that
+// already list target drop their source item.
+func moveGuideItems(tx *gorm.DB, sourceID, targetID uuid.UUID) error {
+	guideIDs, err := BumpGuidesListing(tx, []uuid.UUID{sourceID})
+	if err != nil || len(guideIDs) == 0 {
+		return err
+	}
+
+	err = tx.Where("location_id = ? AND guide_id IN (?)", sourceID,
+		tx.Model(&models.GuideItem{}).Select("guide_id").Where("location_id = ?", targetID)).
+		Delete(&models.GuideItem{}).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(&models.GuideItem{}).Where("location_id = ?", sourceID).Update("location_id", targetID).Error; err != nil {
+		return err
+	}
+	return compactGuideItems(tx, guideIDs)
+}
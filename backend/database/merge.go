@@ -0,0 +1,94 @@
+package database
+
+import (
+	"myarea-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MergeLocations folds source into target: target keeps its own values and
+// takes any fields it is missing from source, tags are combined, guides are
+// rewritten to point at target, and source's ID becomes an alias of target.
+func MergeLocations(sourceID, targetID, mergedBy uuid.UUID) (*models.Location, error) {
+	var target models.Location
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var source models.Location
+		if err := tx.First(&source, sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&target, targetID).Error; err != nil {
+			return err
+		}
+
+		before := models.SnapshotLocation(&target)
+		if target.Description == nil {
+			target.Description = source.Description
+		}
+		if target.Rating == nil {
+			target.Rating = source.Rating
+		}
+		if target.PriceLevel == nil {
+			target.PriceLevel = source.PriceLevel
+		}
+		if target.ImageURL == nil {
+			target.ImageURL = source.ImageURL
+		}
+		if target.WebsiteURL == nil {
+			target.WebsiteURL = source.WebsiteURL
+		}
+		target.Tags = models.NormalizeTags(append(append([]string{}, target.Tags...), source.Tags...))
+
+		if err := tx.Save(&target).Error; err != nil {
+			return err
+		}
+		if _, err := RecordLocationRevision(tx, &target, mergedBy, &before, nil); err != nil {
+			return err
+		}
+
+		// Point guides at the surviving location without listing it twice
+		if err := moveGuideItems(tx, sourceID, targetID); err != nil {
+			return err
+		}
+
+		// Append source's photos to target's gallery
+		err := tx.Exec(`
+			UPDATE location_photos
+			SET location_id = ?, position = position + (SELECT COUNT(*) FROM location_photos WHERE location_id = ?)
+			WHERE location_id = ?`, targetID, targetID, sourceID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Image{}).Where("kind = ? AND subject_id = ?", models.ImageKindLocation, sourceID).Update("subject_id", targetID).Error; err != nil {
+			return err
+		}
+
+		if err := moveReviews(tx, sourceID, targetID); err != nil {
+			return err
+		}
+		if err := moveSavedLocations(tx, sourceID, targetID); err != nil {
+			return err
+		}
+		if err := moveLocationCopies(tx, sourceID, targetID); err != nil {
+			return err
+		}
+
+		// Earlier merges into source now redirect to target
+		if err := tx.Model(&models.LocationAlias{}).Where("location_id = ?", sourceID).Update("location_id", targetID).Error; err != nil {
+			return err
+		}
+		alias := models.LocationAlias{OldID: sourceID, LocationID: targetID, MergedBy: mergedBy}
+		if err := tx.Create(&alias).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("location_id = ?", sourceID).Delete(&models.LocationRevision{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&source).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
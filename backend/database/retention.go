@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"myarea-backend/models"
+	"myarea-backend/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PurgeDeletedLocations permanently removes locations that have been in the
+// trash for longer than retention, along with their photos, history and
+// the aliases that redirect merged-away IDs to them
+func PurgeDeletedLocations(retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+
+	var ids []uuid.UUID
+	err := DB.Unscoped().Model(&models.Location{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	var images []models.Image
+	var purged int64
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("kind = ? AND subject_id IN ?", models.ImageKindLocation, ids).Find(&images).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("location_id IN ?", ids).Delete(&models.LocationPhoto{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kind = ? AND subject_id IN ?", models.ImageKindLocation, ids).Delete(&models.Image{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("location_id IN ?", ids).Delete(&models.Review{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("location_id IN ?", ids).Delete(&models.SavedLocation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("location_id IN ?", ids).Delete(&models.LocationRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("location_id IN ?", ids).Delete(&models.LocationAlias{}).Error; err != nil {
+			return err
+		}
+		// Guide stops go with the locations
+		guideIDs, err := BumpGuidesListing(tx, ids)
+		if err != nil {
+			return err
+		}
+		// Copies keep their author attribution but lose the link
+		if err := tx.Model(&models.Location{}).Unscoped().Where("copied_from_id IN ?", ids).UpdateColumn("copied_from_id", nil).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Location{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+		// Close the gaps the cascaded stops left in their guides
+		if len(guideIDs) == 0 {
+			return nil
+		}
+		return compactGuideItems(tx, guideIDs)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Files go last so a failed transaction never leaves rows without files
+	for _, image := range images {
+		for _, v := range image.Variants {
+			if err := storage.Default.Delete(context.Background(), v.Key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				log.Printf("Failed to delete %s: %v", v.Key, err)
+			}
+		}
+	}
+	return purged, nil
+}
+
+// StartTrashRetention purges expired trash once at startup and then on every interval
+func StartTrashRetention(retention, interval time.Duration) {
+	purge := func() {
+		purged, err := PurgeDeletedLocations(retention)
+		if err != nil {
+			log.Printf("Failed to purge deleted locations: %v", err)
+			return
+		}
+		if purged > 0 {
+			log.Printf("🗑️  Purged %d locations from the trash", purged)
+		}
+	}
+
+	go func() {
+		purge()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			purge()
+		}
+	}()
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"myarea-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshLocationRating recomputes a location's average rating and review
+// count from its reviews. It doesn't touch updated_at, since reviews aren't
+// edits to the location itself.
+func RefreshLocationRating(tx *gorm.DB, locationID uuid.UUID) error {
+	return tx.Exec(`
+		UPDATE locations
+		SET review_count = (SELECT COUNT(*) FROM reviews WHERE location_id = ?),
+			average_rating = (SELECT ROUND(AVG(rating)::numeric, 2) FROM reviews WHERE location_id = ?)
+		WHERE id = ?`, locationID, locationID, locationID).Error
+}
+
+// moveReviews reassigns source's reviews to target. Where a user reviewed
+// both, their review of target is kept.
+func moveReviews(tx *gorm.DB, sourceID, targetID uuid.UUID) error {
+	err := tx.Where("location_id = ? AND user_id IN (?)", sourceID,
+		tx.Model(&models.Review{}).Select("user_id").Where("location_id = ?", targetID)).
+		Delete(&models.Review{}).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Review{}).Where("location_id = ?", sourceID).Update("location_id", targetID).Error; err != nil {
+		return err
+	}
+	return RefreshLocationRating(tx, targetID)
+}
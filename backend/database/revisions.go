@@ -0,0 +1,60 @@
+package database
+
+import (
+	"myarea-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecordLocationRevision stores a revision for location using tx. before is the
+// snapshot prior to the change, or nil for a newly created location. No
+// revision is written (and nil is returned) when nothing changed.
+func RecordLocationRevision(tx *gorm.DB, location *models.Location, authorID uuid.UUID, before *models.LocationSnapshot, restoredFrom *int) (*models.LocationRevision, error) {
+	after := models.SnapshotLocation(location)
+	var base models.LocationSnapshot
+	if before != nil {
+		base = *before
+	}
+
+	changes := models.DiffSnapshots(base, after)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	// Lock the location so concurrent edits can't both take the next number
+	var locked models.Location
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, location.ID).Error; err != nil {
+		return nil, err
+	}
+
+	var last int
+	err := tx.Model(&models.LocationRevision{}).
+		Where("location_id = ?", location.ID).
+		Select("COALESCE(MAX(number), 0)").
+		Scan(&last).Error
+	if err != nil {
+		return nil, err
+	}
+
+	revision := models.LocationRevision{
+		LocationID:   location.ID,
+		Number:       last + 1,
+		AuthorID:     authorID,
+		Changes:      changes,
+		Snapshot:     after,
+		RestoredFrom: restoredFrom,
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// CountLocationRevisions returns how many revisions a location has
+func CountLocationRevisions(locationID uuid.UUID) (int64, error) {
+	var count int64
+	err := DB.Model(&models.LocationRevision{}).Where("location_id = ?", locationID).Count(&count).Error
+	return count, err
+}
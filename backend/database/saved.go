@@ -0,0 +1,77 @@
+package database
+
+import (
+	"log"
+
+	"myarea-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SavedLocationIDs returns which of the given locations the user has saved
+func SavedLocationIDs(userID uuid.UUID, locationIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	saved := map[uuid.UUID]bool{}
+	if len(locationIDs) == 0 {
+		return saved, nil
+	}
+
+	var ids []uuid.UUID
+	err := DB.Model(&models.SavedLocation{}).
+		Where("user_id = ? AND location_id IN ?", userID, locationIDs).
+		Distinct("location_id").
+		Pluck("location_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		saved[id] = true
+	}
+	return saved, nil
+}
+
+// moveSavedLocations reassigns saves of source to target, dropping saves that
+// would duplicate one the user already has for target in the same list
+func moveSavedLocations(tx *gorm.DB, sourceID, targetID uuid.UUID) error {
+	err := tx.Exec(`
+		DELETE FROM saved_locations s
+		WHERE s.location_id = ? AND EXISTS (
+			SELECT 1 FROM saved_locations t
+			WHERE t.location_id = ? AND t.user_id = s.user_id AND t.list_id IS NOT DISTINCT FROM s.list_id
+		)`, sourceID, targetID).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.SavedLocation{}).Where("location_id = ?", sourceID).Update("location_id", targetID).Error
+}
+
+// migrateSavedLocationIndexes enforces one save per user, location and list.
+// Postgres treats NULLs as distinct, so saves without a list get their own
+// partial index. Duplicates left by earlier double-submits are dropped first,
+// keeping the oldest save.
+func migrateSavedLocationIndexes() error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`
+			DELETE FROM saved_locations s
+			USING saved_locations d
+			WHERE s.user_id = d.user_id AND s.location_id = d.location_id
+			AND s.list_id IS NOT DISTINCT FROM d.list_id
+			AND (s.created_at, s.id) > (d.created_at, d.id)`)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("✅ Removed %d duplicate saved locations", result.RowsAffected)
+		}
+		if err := tx.Exec(`
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_saved_location_list
+			ON saved_locations (user_id, location_id, list_id)
+			WHERE list_id IS NOT NULL`).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_saved_location_unlisted
+			ON saved_locations (user_id, location_id)
+			WHERE list_id IS NULL`).Error
+	})
+}
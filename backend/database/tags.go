@@ -0,0 +1,84 @@
+package database
+
+import (
+	"myarea-backend/models"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// TagCount is a tag with the number of locations using it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// CanonicalTags normalizes tags and replaces synonyms with their canonical tag
+func CanonicalTags(tags []string) (pq.StringArray, error) {
+	normalized := models.NormalizeTags(tags)
+	if len(normalized) == 0 {
+		return normalized, nil
+	}
+
+	var aliases []models.TagAlias
+	if err := DB.Where("alias IN ?", []string(normalized)).Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+	if len(aliases) == 0 {
+		return normalized, nil
+	}
+
+	canonical := make(map[string]string, len(aliases))
+	for _, alias := range aliases {
+		canonical[alias.Alias] = alias.Tag
+	}
+	mapped := make([]string, len(normalized))
+	for i, tag := range normalized {
+		if target, ok := canonical[tag]; ok {
+			mapped[i] = target
+		} else {
+			mapped[i] = tag
+		}
+	}
+	// Normalize again to drop duplicates introduced by the mapping
+	return models.NormalizeTags(mapped), nil
+}
+
+// MergeTags records each source tag as an alias of target and rewrites
+// existing locations to use target. It returns the number of locations changed.
+func MergeTags(sources []string, target string) (int64, error) {
+	var updated int64
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, source := range sources {
+			if source == target {
+				continue
+			}
+			alias := models.TagAlias{Alias: source, Tag: target}
+			if err := tx.Save(&alias).Error; err != nil {
+				return err
+			}
+			// Aliases pointing at the merged tag now point at the target
+			if err := tx.Model(&models.TagAlias{}).Where("tag = ?", source).Update("tag", target).Error; err != nil {
+				return err
+			}
+
+			result := tx.Exec(`
+				UPDATE locations
+				SET tags = (
+					SELECT ARRAY(
+						SELECT t
+						FROM unnest(array_replace(tags, ?, ?)) WITH ORDINALITY AS u(t, i)
+						GROUP BY t
+						ORDER BY MIN(i)
+					)
+				)
+				WHERE tags @> ARRAY[?]::text[]`, source, target, source)
+			if result.Error != nil {
+				return result.Error
+			}
+			updated += result.RowsAffected
+		}
+		return nil
+	})
+	return updated, err
+}
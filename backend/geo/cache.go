@@ -0,0 +1,87 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxCacheEntries bounds the cache; it is cleared when full
+const maxCacheEntries = 10000
+
+// CachedGeocoder remembers results, including misses, so repeated lookups of
+// the same address or coordinates don't reach the underlying geocoder
+type CachedGeocoder struct {
+	Geocoder Geocoder
+	TTL      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	place   *Place
+	err     error
+	expires time.Time
+}
+
+// NewCachedGeocoder wraps a geocoder with a cache
+func NewCachedGeocoder(geocoder Geocoder, ttl time.Duration) *CachedGeocoder {
+	return &CachedGeocoder{Geocoder: geocoder, TTL: ttl, entries: map[string]cacheEntry{}}
+}
+
+// Geocode implements Geocoder
+func (g *CachedGeocoder) Geocode(ctx context.Context, address string) (*Place, error) {
+	return g.lookup("q:"+normalizeQuery(address), func() (*Place, error) {
+		return g.Geocoder.Geocode(ctx, address)
+	})
+}
+
+// Reverse implements Geocoder. Coordinates are rounded to about a meter.
+func (g *CachedGeocoder) Reverse(ctx context.Context, lat, lng float64) (*Place, error) {
+	return g.lookup(fmt.Sprintf("r:%.5f,%.5f", lat, lng), func() (*Place, error) {
+		return g.Geocoder.Reverse(ctx, lat, lng)
+	})
+}
+
+func (g *CachedGeocoder) lookup(key string, fetch func() (*Place, error)) (*Place, error) {
+	g.mu.Lock()
+	entry, ok := g.entries[key]
+	g.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.result()
+	}
+
+	place, err := fetch()
+	if err == nil && place == nil {
+		err = ErrNotFound
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		// Failures such as timeouts are not cached
+		return nil, err
+	}
+
+	g.mu.Lock()
+	if len(g.entries) >= maxCacheEntries {
+		g.entries = map[string]cacheEntry{}
+	}
+	entry = cacheEntry{err: err, expires: time.Now().Add(g.TTL)}
+	if place != nil {
+		p := *place
+		entry.place = &p
+	}
+	g.entries[key] = entry
+	g.mu.Unlock()
+	return entry.result()
+}
+
+// result returns a copy so callers can't modify the cached place
+func (e cacheEntry) result() (*Place, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	p := *e.place
+	return &p, nil
+}
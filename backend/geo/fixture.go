@@ -0,0 +1,82 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// FixtureGeocoder is an offline geocoder backed by a fixed set of places.
+// It is meant for tests and local development without network access.
+type FixtureGeocoder struct {
+	mu     sync.RWMutex
+	places map[string]Place
+
+	// MaxReverseDistance bounds how far Reverse will snap to the nearest fixture
+	MaxReverseDistance float64
+}
+
+// NewFixtureGeocoder creates an empty fixture geocoder
+func NewFixtureGeocoder() *FixtureGeocoder {
+	return &FixtureGeocoder{
+		places:             make(map[string]Place),
+		MaxReverseDistance: 2000,
+	}
+}
+
+// Add registers a place, keyed by its address
+func (g *FixtureGeocoder) Add(place Place) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.places[normalizeQuery(place.Address)] = place
+}
+
+// LoadFile reads a JSON array of places from disk
+func (g *FixtureGeocoder) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var places []Place
+	if err := json.Unmarshal(data, &places); err != nil {
+		return err
+	}
+	for _, place := range places {
+		g.Add(place)
+	}
+	return nil
+}
+
+// Geocode returns the fixture whose address matches exactly (ignoring case and spacing)
+func (g *FixtureGeocoder) Geocode(ctx context.Context, address string) (*Place, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	place, ok := g.places[normalizeQuery(address)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &place, nil
+}
+
+// Reverse returns the nearest fixture within MaxReverseDistance meters
+func (g *FixtureGeocoder) Reverse(ctx context.Context, lat, lng float64) (*Place, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var nearest *Place
+	best := g.MaxReverseDistance
+	for _, place := range g.places {
+		d := DistanceMeters(lat, lng, place.Latitude, place.Longitude)
+		if d <= best {
+			p := place
+			nearest = &p
+			best = d
+		}
+	}
+	if nearest == nil {
+		return nil, ErrNotFound
+	}
+	return nearest, nil
+}
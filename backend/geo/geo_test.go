@@ -0,0 +1,177 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testFixtures() *FixtureGeocoder {
+	g := NewFixtureGeocoder()
+	g.Add(Place{Latitude: 37.7596, Longitude: -122.4269, Address: "600 Guerrero St, San Francisco", City: "San Francisco"})
+	g.Add(Place{Latitude: 37.8080, Longitude: -122.4177, Address: "Pier 39, San Francisco", City: "San Francisco"})
+	return g
+}
+
+func TestFixtureGeocoder(t *testing.T) {
+	g := testFixtures()
+	ctx := context.Background()
+
+	place, err := g.Geocode(ctx, "  600 guerrero st,   San Francisco ")
+	if err != nil {
+		t.Fatalf("Geocode: %v", err)
+	}
+	if place.Latitude != 37.7596 || place.City != "San Francisco" {
+		t.Errorf("Geocode = %+v", place)
+	}
+
+	if _, err := g.Geocode(ctx, "1 Nowhere Lane"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Geocode unknown address: err = %v, want ErrNotFound", err)
+	}
+
+	place, err = g.Reverse(ctx, 37.8085, -122.4180)
+	if err != nil {
+		t.Fatalf("Reverse: %v", err)
+	}
+	if place.Address != "Pier 39, San Francisco" {
+		t.Errorf("Reverse snapped to %q", place.Address)
+	}
+
+	if _, err := g.Reverse(ctx, 40.7128, -74.0060); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Reverse far away: err = %v, want ErrNotFound", err)
+	}
+}
+
+// countingGeocoder counts calls to the wrapped geocoder
+type countingGeocoder struct {
+	Geocoder
+	calls int
+}
+
+func (g *countingGeocoder) Geocode(ctx context.Context, address string) (*Place, error) {
+	g.calls++
+	return g.Geocoder.Geocode(ctx, address)
+}
+
+func (g *countingGeocoder) Reverse(ctx context.Context, lat, lng float64) (*Place, error) {
+	g.calls++
+	return g.Geocoder.Reverse(ctx, lat, lng)
+}
+
+func TestCachedGeocoder(t *testing.T) {
+	inner := &countingGeocoder{Geocoder: testFixtures()}
+	g := NewCachedGeocoder(inner, time.Hour)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		place, err := g.Geocode(ctx, "Pier 39, San Francisco")
+		if err != nil {
+			t.Fatalf("Geocode: %v", err)
+		}
+		// Callers get their own copy
+		place.City = "changed"
+	}
+	if _, err := g.Geocode(ctx, "PIER 39,  san francisco"); err != nil {
+		t.Fatalf("Geocode: %v", err)
+	}
+	place, _ := g.Geocode(ctx, "Pier 39, San Francisco")
+	if place.City != "San Francisco" {
+		t.Errorf("cached place was modified: %+v", place)
+	}
+	if inner.calls != 1 {
+		t.Errorf("underlying geocoder called %d times, want 1", inner.calls)
+	}
+
+	// Misses are cached too
+	for i := 0; i < 2; i++ {
+		if _, err := g.Geocode(ctx, "1 Nowhere Lane"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v, want ErrNotFound", err)
+		}
+	}
+	if inner.calls != 2 {
+		t.Errorf("underlying geocoder called %d times, want 2", inner.calls)
+	}
+
+	g.Reverse(ctx, 37.8080, -122.4177)
+	g.Reverse(ctx, 37.808001, -122.417701)
+	if inner.calls != 3 {
+		t.Errorf("underlying geocoder called %d times, want 3", inner.calls)
+	}
+}
+
+func TestNominatimGeocoder(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("User-Agent") == "" {
+			t.Error("missing User-Agent")
+		}
+		switch r.URL.Path {
+		case "/search":
+			if r.URL.Query().Get("q") == "nowhere" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"lat":"52.5163","lon":"13.3777","display_name":"Brandenburger Tor, Berlin","address":{"suburb":"Mitte","city":"Berlin"}}]`))
+		case "/reverse":
+			w.Write([]byte(`{"error":"Unable to geocode"}`))
+		}
+	}))
+	defer server.Close()
+
+	g := NewNominatimGeocoder(server.URL+"/", "")
+	g.Interval = 50 * time.Millisecond
+	ctx := context.Background()
+
+	start := time.Now()
+	place, err := g.Geocode(ctx, "Brandenburger Tor")
+	if err != nil {
+		t.Fatalf("Geocode: %v", err)
+	}
+	if place.Latitude != 52.5163 || place.Longitude != 13.3777 || place.City != "Berlin" {
+		t.Errorf("Geocode = %+v", place)
+	}
+	if _, err := g.Geocode(ctx, "nowhere"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Geocode with no results: err = %v, want ErrNotFound", err)
+	}
+	if _, err := g.Reverse(ctx, 0, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Reverse error response: err = %v, want ErrNotFound", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("three requests took %v, want at least two intervals", elapsed)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("server saw %d requests, want 3", n)
+	}
+}
+
+func TestNominatimGeocoderWaitHonoursContext(t *testing.T) {
+	g := NewNominatimGeocoder("http://127.0.0.1:0", "")
+	g.Interval = time.Hour
+	g.next = time.Now().Add(time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := g.Geocode(ctx, "anything"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestPolygonArea(t *testing.T) {
+	square := Polygon{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 0}}
+	if got := square.Area(); got != 4 {
+		t.Errorf("square area = %v, want 4", got)
+	}
+	// Winding direction doesn't matter
+	reversed := Polygon{{0, 0}, {0, 2}, {2, 2}, {2, 0}, {0, 0}}
+	if got := reversed.Area(); got != 4 {
+		t.Errorf("reversed area = %v, want 4", got)
+	}
+	if got := (Polygon{}).Area(); got != 0 {
+		t.Errorf("empty area = %v", got)
+	}
+}
@@ -0,0 +1,64 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a geocoder has no match for a query
+var ErrNotFound = errors.New("geocoding: no match found")
+
+// Place is a geocoding result
+type Place struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Address   string  `json:"address"`
+	City      string  `json:"city"`
+}
+
+// Geocoder resolves addresses to coordinates and coordinates back to places
+type Geocoder interface {
+	// Geocode returns the best match for a free-text address
+	Geocode(ctx context.Context, address string) (*Place, error)
+	// Reverse returns the place at the given coordinates
+	Reverse(ctx context.Context, lat, lng float64) (*Place, error)
+}
+
+// DefaultGeocoder is the geocoder used by the handlers. It is nil when geocoding is disabled.
+var DefaultGeocoder Geocoder
+
+// SetupGeocoder configures DefaultGeocoder from the environment.
+//
+// GEOCODER selects the implementation: "none" (default), "fixture" or
+// "nominatim". Nominatim is only used when asked for explicitly; requests to
+// it are limited to one per second and results are cached.
+func SetupGeocoder() {
+	switch strings.ToLower(os.Getenv("GEOCODER")) {
+	case "nominatim":
+		nominatim := NewNominatimGeocoder(os.Getenv("GEOCODER_URL"), os.Getenv("GEOCODER_USER_AGENT"))
+		DefaultGeocoder = NewCachedGeocoder(nominatim, 24*time.Hour)
+		log.Println("✅ Using Nominatim geocoder")
+	case "fixture":
+		path := os.Getenv("GEOCODER_FIXTURES")
+		fixture := NewFixtureGeocoder()
+		if path != "" {
+			if err := fixture.LoadFile(path); err != nil {
+				log.Fatalf("Failed to load geocoder fixtures: %v", err)
+			}
+		}
+		DefaultGeocoder = fixture
+		log.Println("✅ Using fixture geocoder")
+	default:
+		DefaultGeocoder = nil
+		log.Println("⏭️  Geocoding disabled")
+	}
+}
+
+// normalizeQuery lowercases and collapses whitespace in an address
+func normalizeQuery(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
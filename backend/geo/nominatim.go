@@ -0,0 +1,173 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultNominatimURL is the public OpenStreetMap Nominatim endpoint
+const DefaultNominatimURL = "https://nominatim.openstreetmap.org"
+
+// nominatimInterval is the minimum spacing between requests required by the
+// public Nominatim usage policy
+const nominatimInterval = time.Second
+
+// NominatimGeocoder talks to a Nominatim-compatible HTTP API
+type NominatimGeocoder struct {
+	BaseURL   string
+	UserAgent string
+	Client    *http.Client
+	// Interval is the minimum time between requests
+	Interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewNominatimGeocoder creates a geocoder for the given base URL and user agent
+func NewNominatimGeocoder(baseURL, userAgent string) *NominatimGeocoder {
+	if baseURL == "" {
+		baseURL = DefaultNominatimURL
+	}
+	if userAgent == "" {
+		// Nominatim's usage policy requires an identifying user agent
+		userAgent = "MyArea/1.0"
+	}
+	return &NominatimGeocoder{
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		UserAgent: userAgent,
+		Client:    &http.Client{Timeout: 10 * time.Second},
+		Interval:  nominatimInterval,
+	}
+}
+
+// nominatimPlace is a single entry in a Nominatim jsonv2 response
+type nominatimPlace struct {
+	Lat         string            `json:"lat"`
+	Lon         string            `json:"lon"`
+	DisplayName string            `json:"display_name"`
+	Address     map[string]string `json:"address"`
+	Error       string            `json:"error"`
+}
+
+// Geocode looks up an address with /search
+func (g *NominatimGeocoder) Geocode(ctx context.Context, address string) (*Place, error) {
+	params := url.Values{}
+	params.Set("q", address)
+	params.Set("format", "jsonv2")
+	params.Set("addressdetails", "1")
+	params.Set("limit", "1")
+
+	var results []nominatimPlace
+	if err := g.get(ctx, "/search", params, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
+	return results[0].toPlace()
+}
+
+// Reverse looks up coordinates with /reverse
+func (g *NominatimGeocoder) Reverse(ctx context.Context, lat, lng float64) (*Place, error) {
+	params := url.Values{}
+	params.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	params.Set("lon", strconv.FormatFloat(lng, 'f', -1, 64))
+	params.Set("format", "jsonv2")
+	params.Set("addressdetails", "1")
+
+	var result nominatimPlace
+	if err := g.get(ctx, "/reverse", params, &result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, ErrNotFound
+	}
+	return result.toPlace()
+}
+
+// wait blocks until the next request slot, or until ctx is done
+func (g *NominatimGeocoder) wait(ctx context.Context) error {
+	g.mu.Lock()
+	now := time.Now()
+	slot := g.next
+	if slot.Before(now) {
+		slot = now
+	}
+	g.next = slot.Add(g.Interval)
+	g.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *NominatimGeocoder) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	if err := g.wait(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.BaseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", g.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("geocoding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("geocoding request failed: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid geocoding response: %w", err)
+	}
+	return nil
+}
+
+func (p nominatimPlace) toPlace() (*Place, error) {
+	lat, err := strconv.ParseFloat(p.Lat, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude in geocoding response: %w", err)
+	}
+	lng, err := strconv.ParseFloat(p.Lon, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude in geocoding response: %w", err)
+	}
+	return &Place{
+		Latitude:  lat,
+		Longitude: lng,
+		Address:   p.DisplayName,
+		City:      p.city(),
+	}, nil
+}
+
+// city picks the most specific settlement name Nominatim returned
+func (p nominatimPlace) city() string {
+	for _, key := range []string{"city", "town", "village", "hamlet", "municipality", "county"} {
+		if v := p.Address[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package geo
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+)
+
+// Point is a [longitude, latitude] pair, matching GeoJSON axis order
+type Point [2]float64
+
+// Lng returns the longitude of the point
+func (p Point) Lng() float64 { return p[0] }
+
+// Lat returns the latitude of the point
+func (p Point) Lat() float64 { return p[1] }
+
+// Polygon is a single closed ring of points. It is stored as JSON.
+type Polygon []Point
+
+// BBox is an axis-aligned bounding box
+type BBox struct {
+	MinLatitude  float64 `json:"min_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+}
+
+// Contains reports whether the point lies inside the polygon (ray casting)
+func (p Polygon) Contains(lat, lng float64) bool {
+	if len(p) < 3 {
+		return false
+	}
+	inside := false
+	j := len(p) - 1
+	for i := 0; i < len(p); i++ {
+		xi, yi := p[i].Lng(), p[i].Lat()
+		xj, yj := p[j].Lng(), p[j].Lat()
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+		j = i
+	}
+	return inside
+}
+
+// Area returns the area enclosed by the polygon in square degrees. It is only
+// meant for comparing nearby polygons.
+func (p Polygon) Area() float64 {
+	sum := 0.0
+	for i := range p {
+		j := (i + 1) % len(p)
+		sum += p[i].Lng()*p[j].Lat() - p[j].Lng()*p[i].Lat()
+	}
+	return math.Abs(sum) / 2
+}
+
+// Bounds returns the bounding box of the polygon
+func (p Polygon) Bounds() BBox {
+	if len(p) == 0 {
+		return BBox{}
+	}
+	box := BBox{
+		MinLatitude:  math.Inf(1),
+		MinLongitude: math.Inf(1),
+		MaxLatitude:  math.Inf(-1),
+		MaxLongitude: math.Inf(-1),
+	}
+	for _, pt := range p {
+		box.MinLatitude = math.Min(box.MinLatitude, pt.Lat())
+		box.MaxLatitude = math.Max(box.MaxLatitude, pt.Lat())
+		box.MinLongitude = math.Min(box.MinLongitude, pt.Lng())
+		box.MaxLongitude = math.Max(box.MaxLongitude, pt.Lng())
+	}
+	return box
+}
+
+// Contains reports whether the point lies inside the box
+func (b BBox) Contains(lat, lng float64) bool {
+	return lat >= b.MinLatitude && lat <= b.MaxLatitude &&
+		lng >= b.MinLongitude && lng <= b.MaxLongitude
+}
+
+// Value implements driver.Valuer
+func (p Polygon) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
+
+// Scan implements sql.Scanner
+func (p *Polygon) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return errors.New("geo: unsupported polygon value")
+	}
+}
@@ -0,0 +1,130 @@
+package geofile
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func testPlaces() []Place {
+	rating := 4
+	return []Place{
+		{
+			ID:          "1",
+			Name:        "=HYPERLINK(\"http://evil.example\",\"Click\")",
+			Description: "+1 for the view",
+			Category:    "viewpoint",
+			Address:     "-Top of the hill",
+			City:        "@Town",
+			Latitude:    -33.8568,
+			Longitude:   151.2153,
+			Tags:        []string{"sunset", "free"},
+			Rating:      &rating,
+			Author:      "ana",
+		},
+		{
+			ID:        "2",
+			Name:      "Café Olé",
+			Category:  "cafe",
+			Latitude:  48.8566,
+			Longitude: -2.3522,
+		},
+	}
+}
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(FormatCSV, &buf, "Test", testPlaces()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV back: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header and 2 places", len(rows))
+	}
+	for _, cell := range rows[1] {
+		if cell != "" && strings.ContainsRune("=+-@", rune(cell[0])) && cell != "-33.8568" {
+			t.Errorf("cell %q starts with a formula character", cell)
+		}
+	}
+	if rows[1][1] != `'=HYPERLINK("http://evil.example","Click")` {
+		t.Errorf("name cell = %q", rows[1][1])
+	}
+	// Coordinates stay numeric
+	if rows[1][6] != "-33.8568" || rows[2][7] != "-2.3522" {
+		t.Errorf("coordinates = %q, %q", rows[1][6], rows[2][7])
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	places := testPlaces()
+	var buf bytes.Buffer
+	if err := Write(FormatCSV, &buf, "Test", places); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	records, err := Read(FormatCSV, &buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(records) != len(places) {
+		t.Fatalf("got %d records, want %d", len(records), len(places))
+	}
+	got := records[0].Properties
+	want := places[0]
+	for key, value := range map[string]string{
+		"name":        want.Name,
+		"description": want.Description,
+		"address":     want.Address,
+		"city":        want.City,
+		"latitude":    "-33.8568",
+		"tags":        "sunset,free",
+		"rating":      "4",
+	} {
+		if got[key] != value {
+			t.Errorf("%s = %q, want %q", key, got[key], value)
+		}
+	}
+	if records[1].Properties["name"] != "Café Olé" {
+		t.Errorf("name = %q", records[1].Properties["name"])
+	}
+}
+
+func TestWriteReadFormats(t *testing.T) {
+	for _, format := range []Format{FormatGeoJSON, FormatKML, FormatGPX} {
+		var buf bytes.Buffer
+		if err := Write(format, &buf, "Test <&>", testPlaces()); err != nil {
+			t.Fatalf("%s Write: %v", format, err)
+		}
+		records, err := Read(format, &buf)
+		if err != nil {
+			t.Fatalf("%s Read: %v", format, err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("%s: got %d records, want 2", format, len(records))
+		}
+		r := records[1]
+		if r.Latitude == nil || *r.Latitude != 48.8566 || r.Longitude == nil || *r.Longitude != -2.3522 {
+			t.Errorf("%s: coordinates = %v, %v", format, r.Latitude, r.Longitude)
+		}
+		if r.Properties["name"] != "Café Olé" {
+			t.Errorf("%s: name = %q", format, r.Properties["name"])
+		}
+	}
+}
+
+func TestFormatDetection(t *testing.T) {
+	if f, err := DetectFormat("places.GeoJSON"); err != nil || f != FormatGeoJSON {
+		t.Errorf("DetectFormat = %q, %v", f, err)
+	}
+	if _, err := DetectFormat("places.xlsx"); err == nil {
+		t.Error("DetectFormat accepted xlsx")
+	}
+	if f, ok := FormatForMediaType(" text/csv; charset=utf-8"); !ok || f != FormatCSV {
+		t.Errorf("FormatForMediaType = %q, %v", f, ok)
+	}
+}
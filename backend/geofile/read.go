@@ -0,0 +1,295 @@
+// Package geofile reads and writes places in common geodata formats
+// (CSV, GeoJSON, KML and GPX).
+package geofile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Format is a supported file format
+type Format string
+
+// Supported formats
+const (
+	FormatCSV     Format = "csv"
+	FormatGeoJSON Format = "geojson"
+	FormatKML     Format = "kml"
+	FormatGPX     Format = "gpx"
+)
+
+// ErrUnknownFormat is returned when a format can't be determined or isn't supported
+var ErrUnknownFormat = errors.New("unsupported file format")
+
+// Record is one place read from a file. Properties are keyed by the file's
+// own column or property names; coordinates come from the geometry when the
+// format has one.
+type Record struct {
+	Row        int               `json:"row"`
+	Properties map[string]string `json:"properties"`
+	Latitude   *float64          `json:"latitude,omitempty"`
+	Longitude  *float64          `json:"longitude,omitempty"`
+}
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case FormatCSV, FormatGeoJSON, FormatKML, FormatGPX:
+		return f, nil
+	case "json":
+		return FormatGeoJSON, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// DetectFormat guesses the format from a file name
+func DetectFormat(filename string) (Format, error) {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	return ParseFormat(ext)
+}
+
+// Read parses all records from r
+func Read(format Format, r io.Reader) ([]Record, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatGeoJSON:
+		return readGeoJSON(r)
+	case FormatKML:
+		return readKML(r)
+	case FormatGPX:
+		return readGPX(r)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func readCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	if len(header) > 0 {
+		// Spreadsheet exports often start with a byte order mark
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	var records []Record
+	row := 1
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		row++
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV on line %d: %w", row, err)
+		}
+
+		props := make(map[string]string, len(header))
+		for i, key := range header {
+			if i < len(fields) {
+				props[strings.TrimSpace(key)] = unescapeCSVText(strings.TrimSpace(fields[i]))
+			}
+		}
+		records = append(records, Record{Row: row - 1, Properties: props})
+	}
+	return records, nil
+}
+
+// unescapeCSVText drops the apostrophe csvText adds in front of formula
+// characters, so exported files import unchanged
+func unescapeCSVText(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+type geoJSONFeature struct {
+	Type     string `json:"type"`
+	Geometry *struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+	Features   []geoJSONFeature       `json:"features"`
+}
+
+func readGeoJSON(r io.Reader) ([]Record, error) {
+	var doc geoJSONFeature
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	features := doc.Features
+	switch doc.Type {
+	case "FeatureCollection":
+	case "Feature":
+		features = []geoJSONFeature{doc}
+	default:
+		return nil, errors.New("invalid GeoJSON: expected a Feature or FeatureCollection")
+	}
+
+	records := make([]Record, 0, len(features))
+	for i, feature := range features {
+		record := Record{Row: i + 1, Properties: make(map[string]string, len(feature.Properties))}
+		for key, value := range feature.Properties {
+			record.Properties[key] = stringifyProperty(value)
+		}
+		if feature.Geometry != nil && feature.Geometry.Type == "Point" {
+			var coords []float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err == nil && len(coords) >= 2 {
+				// GeoJSON positions are [longitude, latitude]
+				record.Longitude = &coords[0]
+				record.Latitude = &coords[1]
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// stringifyProperty flattens a JSON property value; arrays become comma-separated
+func stringifyProperty(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, stringifyProperty(item))
+		}
+		return strings.Join(parts, ",")
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+type kmlPlacemark struct {
+	Name         string `xml:"name"`
+	Description  string `xml:"description"`
+	Address      string `xml:"address"`
+	ExtendedData struct {
+		Data []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:"value"`
+		} `xml:"Data"`
+	} `xml:"ExtendedData"`
+	Point *struct {
+		Coordinates string `xml:"coordinates"`
+	} `xml:"Point"`
+}
+
+func readKML(r io.Reader) ([]Record, error) {
+	decoder := xml.NewDecoder(r)
+	var records []Record
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid KML: %w", err)
+		}
+
+		// Placemarks can be nested in any number of Documents and Folders
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+		var placemark kmlPlacemark
+		if err := decoder.DecodeElement(&placemark, &start); err != nil {
+			return nil, fmt.Errorf("invalid KML placemark: %w", err)
+		}
+
+		record := Record{Row: len(records) + 1, Properties: map[string]string{}}
+		setIfPresent(record.Properties, "name", placemark.Name)
+		setIfPresent(record.Properties, "description", placemark.Description)
+		setIfPresent(record.Properties, "address", placemark.Address)
+		for _, data := range placemark.ExtendedData.Data {
+			setIfPresent(record.Properties, data.Name, data.Value)
+		}
+		if placemark.Point != nil {
+			// KML coordinates are "longitude,latitude[,altitude]"
+			parts := strings.Split(strings.TrimSpace(placemark.Point.Coordinates), ",")
+			if len(parts) >= 2 {
+				lng, lngErr := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+				lat, latErr := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+				if lngErr == nil && latErr == nil {
+					record.Latitude = &lat
+					record.Longitude = &lng
+				}
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+type gpxDocument struct {
+	Waypoints []struct {
+		Lat  float64 `xml:"lat,attr"`
+		Lon  float64 `xml:"lon,attr"`
+		Name string  `xml:"name"`
+		Desc string  `xml:"desc"`
+		Cmt  string  `xml:"cmt"`
+		Type string  `xml:"type"`
+		Link []struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+	} `xml:"wpt"`
+}
+
+func readGPX(r io.Reader) ([]Record, error) {
+	var doc gpxDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid GPX: %w", err)
+	}
+
+	records := make([]Record, 0, len(doc.Waypoints))
+	for i, wpt := range doc.Waypoints {
+		lat, lng := wpt.Lat, wpt.Lon
+		record := Record{
+			Row:        i + 1,
+			Properties: map[string]string{},
+			Latitude:   &lat,
+			Longitude:  &lng,
+		}
+		setIfPresent(record.Properties, "name", wpt.Name)
+		setIfPresent(record.Properties, "description", wpt.Desc)
+		if wpt.Desc == "" {
+			setIfPresent(record.Properties, "description", wpt.Cmt)
+		}
+		setIfPresent(record.Properties, "category", wpt.Type)
+		if len(wpt.Link) > 0 {
+			setIfPresent(record.Properties, "website_url", wpt.Link[0].Href)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func setIfPresent(props map[string]string, key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		props[key] = value
+	}
+}
@@ -0,0 +1,323 @@
+package geofile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Place is one location to export
+type Place struct {
+	ID          string
+	Name        string
+	Description string
+	Category    string
+	Address     string
+	City        string
+	Latitude    float64
+	Longitude   float64
+	Tags        []string
+	Rating      *int
+	PriceLevel  *int
+	WebsiteURL  string
+	ImageURL    string
+	Author      string
+	UpdatedAt   time.Time
+}
+
+// ContentType returns the MIME type for a format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatGeoJSON:
+		return "application/geo+json"
+	case FormatKML:
+		return "application/vnd.google-earth.kml+xml"
+	case FormatGPX:
+		return "application/gpx+xml"
+	}
+	return "application/octet-stream"
+}
+
+// Extension returns the usual file extension for a format
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// FormatForMediaType maps a media type from an Accept header to a format
+func FormatForMediaType(mediaType string) (Format, bool) {
+	switch strings.ToLower(strings.TrimSpace(strings.Split(mediaType, ";")[0])) {
+	case "text/csv":
+		return FormatCSV, true
+	case "application/geo+json", "application/vnd.geo+json":
+		return FormatGeoJSON, true
+	case "application/vnd.google-earth.kml+xml":
+		return FormatKML, true
+	case "application/gpx+xml":
+		return FormatGPX, true
+	}
+	return "", false
+}
+
+// Write encodes places in the given format. name is used as the document
+// title where the format has one.
+func Write(format Format, w io.Writer, name string, places []Place) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, places)
+	case FormatGeoJSON:
+		return writeGeoJSON(w, name, places)
+	case FormatKML:
+		return writeKML(w, name, places)
+	case FormatGPX:
+		return writeGPX(w, name, places)
+	}
+	return ErrUnknownFormat
+}
+
+// csvColumns use the same names the importer recognizes, so exports round-trip
+var csvColumns = []string{
+	"id", "name", "description", "category", "address", "city", "latitude", "longitude",
+	"tags", "rating", "price_level", "website_url", "image_url", "author",
+}
+
+func writeCSV(w io.Writer, places []Place) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, p := range places {
+		row := []string{
+			p.ID,
+			csvText(p.Name),
+			csvText(p.Description),
+			csvText(p.Category),
+			csvText(p.Address),
+			csvText(p.City),
+			strconv.FormatFloat(p.Latitude, 'f', -1, 64),
+			strconv.FormatFloat(p.Longitude, 'f', -1, 64),
+			csvText(strings.Join(p.Tags, ",")),
+			formatOptionalInt(p.Rating),
+			formatOptionalInt(p.PriceLevel),
+			csvText(p.WebsiteURL),
+			csvText(p.ImageURL),
+			csvText(p.Author),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvText guards free-text cells against spreadsheet formula injection by
+// prefixing values that a spreadsheet would evaluate with an apostrophe.
+// Numeric columns are written as-is so negative coordinates stay numbers.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune(formulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// formulaPrefixes are the leading characters spreadsheets treat as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+func formatOptionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+type geoJSONOutFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   geoJSONOutPoint        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONOutPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+func writeGeoJSON(w io.Writer, name string, places []Place) error {
+	features := make([]geoJSONOutFeature, 0, len(places))
+	for _, p := range places {
+		props := map[string]interface{}{
+			"name":     p.Name,
+			"category": p.Category,
+			"address":  p.Address,
+			"city":     p.City,
+			"tags":     nonNilStrings(p.Tags),
+		}
+		if p.Description != "" {
+			props["description"] = p.Description
+		}
+		if p.Rating != nil {
+			props["rating"] = *p.Rating
+		}
+		if p.PriceLevel != nil {
+			props["price_level"] = *p.PriceLevel
+		}
+		if p.WebsiteURL != "" {
+			props["website_url"] = p.WebsiteURL
+		}
+		if p.ImageURL != "" {
+			props["image_url"] = p.ImageURL
+		}
+		if p.Author != "" {
+			props["author"] = p.Author
+		}
+		features = append(features, geoJSONOutFeature{
+			Type: "Feature",
+			ID:   p.ID,
+			// RFC 7946 positions are [longitude, latitude]
+			Geometry:   geoJSONOutPoint{Type: "Point", Coordinates: [2]float64{p.Longitude, p.Latitude}},
+			Properties: props,
+		})
+	}
+
+	return json.NewEncoder(w).Encode(struct {
+		Type     string              `json:"type"`
+		Name     string              `json:"name,omitempty"`
+		Features []geoJSONOutFeature `json:"features"`
+	}{
+		Type:     "FeatureCollection",
+		Name:     name,
+		Features: features,
+	})
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+type kmlOutData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlOutPlacemark struct {
+	ID           string       `xml:"id,attr,omitempty"`
+	Name         string       `xml:"name"`
+	Description  string       `xml:"description,omitempty"`
+	Address      string       `xml:"address,omitempty"`
+	ExtendedData []kmlOutData `xml:"ExtendedData>Data,omitempty"`
+	Coordinates  string       `xml:"Point>coordinates"`
+}
+
+func writeKML(w io.Writer, name string, places []Place) error {
+	placemarks := make([]kmlOutPlacemark, 0, len(places))
+	for _, p := range places {
+		data := []kmlOutData{{Name: "category", Value: p.Category}}
+		if p.City != "" {
+			data = append(data, kmlOutData{Name: "city", Value: p.City})
+		}
+		if len(p.Tags) > 0 {
+			data = append(data, kmlOutData{Name: "tags", Value: strings.Join(p.Tags, ",")})
+		}
+		if p.Rating != nil {
+			data = append(data, kmlOutData{Name: "rating", Value: strconv.Itoa(*p.Rating)})
+		}
+		if p.WebsiteURL != "" {
+			data = append(data, kmlOutData{Name: "website_url", Value: p.WebsiteURL})
+		}
+		placemarks = append(placemarks, kmlOutPlacemark{
+			ID:           p.ID,
+			Name:         p.Name,
+			Description:  p.Description,
+			Address:      p.Address,
+			ExtendedData: data,
+			Coordinates:  formatCoord(p.Longitude) + "," + formatCoord(p.Latitude),
+		})
+	}
+
+	doc := struct {
+		XMLName    xml.Name          `xml:"kml"`
+		Namespace  string            `xml:"xmlns,attr"`
+		Name       string            `xml:"Document>name"`
+		Placemarks []kmlOutPlacemark `xml:"Document>Placemark"`
+	}{
+		Namespace:  "http://www.opengis.net/kml/2.2",
+		Name:       name,
+		Placemarks: placemarks,
+	}
+	return writeXML(w, doc)
+}
+
+type gpxOutLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type gpxOutWaypoint struct {
+	Lat  string      `xml:"lat,attr"`
+	Lon  string      `xml:"lon,attr"`
+	Time string      `xml:"time,omitempty"`
+	Name string      `xml:"name"`
+	Desc string      `xml:"desc,omitempty"`
+	Link *gpxOutLink `xml:"link,omitempty"`
+	Type string      `xml:"type,omitempty"`
+}
+
+func writeGPX(w io.Writer, name string, places []Place) error {
+	waypoints := make([]gpxOutWaypoint, 0, len(places))
+	for _, p := range places {
+		wpt := gpxOutWaypoint{
+			Lat:  formatCoord(p.Latitude),
+			Lon:  formatCoord(p.Longitude),
+			Name: p.Name,
+			Desc: p.Description,
+			Type: p.Category,
+		}
+		if !p.UpdatedAt.IsZero() {
+			wpt.Time = p.UpdatedAt.UTC().Format(time.RFC3339)
+		}
+		if p.WebsiteURL != "" {
+			wpt.Link = &gpxOutLink{Href: p.WebsiteURL}
+		}
+		waypoints = append(waypoints, wpt)
+	}
+
+	doc := struct {
+		XMLName   xml.Name         `xml:"gpx"`
+		Version   string           `xml:"version,attr"`
+		Creator   string           `xml:"creator,attr"`
+		Namespace string           `xml:"xmlns,attr"`
+		Name      string           `xml:"metadata>name,omitempty"`
+		Waypoints []gpxOutWaypoint `xml:"wpt"`
+	}{
+		Version:   "1.1",
+		Creator:   "MyArea",
+		Namespace: "http://www.topografix.com/GPX/1/1",
+		Name:      name,
+		Waypoints: waypoints,
+	}
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', 7, 64)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"os"
 	"time"
 
@@ -83,6 +84,7 @@ func Register(c *fiber.Ctx) error {
 		Email:       req.Email,
 		Username:    req.Username,
 		DisplayName: req.DisplayName,
+		Role:        models.RoleUser,
 	}
 
 	if err := database.DB.Create(&user).Error; err != nil {
@@ -91,6 +93,12 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
+	// The account works right away; verifying the email is only needed for
+	// things addressed to it, such as guide invitations
+	if err := sendEmailVerification(c.UserContext(), &user); err != nil {
+		log.Printf("Failed to start email verification for %s: %v", user.ID, err)
+	}
+
 	// For now, we'll store the hashed password in a separate table or handle it differently
 	// This is a simplified version - in production, you'd want a proper user_auth table
 
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"myarea-backend/database"
+	"myarea-backend/ical"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// calendarRefresh is how often calendar apps are asked to reload a guide feed
+const calendarRefresh = time.Hour
+
+// GetGuideItineraryCalendar returns a guide's itinerary as an iCalendar file.
+// For public guides the URL doubles as a subscribable feed.
+func GetGuideItineraryCalendar(c *fiber.Ctx) error {
+	guideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid guide ID",
+		})
+	}
+
+	viewerID := optionalUserID(c)
+	var guide models.Guide
+	if err := visibleGuides(database.DB, viewerID).First(&guide, guideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Guide not found",
+		})
+	}
+
+	return sendGuideCalendar(c, &guide, viewerID)
+}
+
+// GetSharedGuideItineraryCalendar returns the iCalendar feed of a guide opened
+// through a share link, so private guides can be subscribed to too
+func GetSharedGuideItineraryCalendar(c *fiber.Ctx) error {
+	guide, _, err := findSharedGuide(c)
+	if err != nil {
+		return err
+	}
+	return sendGuideCalendar(c, guide, optionalUserID(c))
+}
+
+// sendGuideCalendar writes one event per scheduled stop on a dated trip.
+// Stops at a start time or in a slot are timed events; stops with only a day
+// are all-day events. Unscheduled stops and guides without dates have none.
+func sendGuideCalendar(c *fiber.Ctx, guide *models.Guide, viewerID *uuid.UUID) error {
+	stops, err := guideStops(guide.ID, viewerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export itinerary",
+		})
+	}
+	itinerary, err := buildItinerary(guide, stops)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export itinerary",
+		})
+	}
+
+	locations := make([]models.Location, 0, len(stops))
+	for _, stop := range stops {
+		if stop.Location != nil {
+			locations = append(locations, *stop.Location)
+		}
+	}
+	zones, err := locationZones(locations)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export itinerary",
+		})
+	}
+
+	cal := ical.Calendar{
+		ProdID:          "-//MyArea//Guide Itinerary//EN",
+		Name:            guide.Title,
+		RefreshInterval: calendarRefresh,
+	}
+	for _, day := range itinerary.Days {
+		date := tripDate(guide, day.Day)
+		if date == nil {
+			continue
+		}
+		for _, stop := range day.Stops {
+			if stop.Location == nil {
+				continue
+			}
+			cal.Events = append(cal.Events, stopEvent(guide, &stop.GuideItem, stop.Location, *date, zoneFor(zones, stop.Location.CityID)))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Write(&buf); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export itinerary",
+		})
+	}
+
+	filename := models.NormalizeTag(guide.Title)
+	if filename == "" {
+		filename = "itinerary"
+	}
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s.ics"`, filename))
+	return c.Send(buf.Bytes())
+}
+
+// stopEvent turns a planned stop into a calendar event on the given date
+func stopEvent(guide *models.Guide, item *models.GuideItem, location *models.Location, date time.Time, zone *time.Location) ical.Event {
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, zone)
+	at := func(minute int) time.Time { return midnight.Add(time.Duration(minute) * time.Minute) }
+
+	event := ical.Event{
+		UID:          item.ID.String() + "@myarea",
+		Sequence:     guide.Version,
+		Stamp:        guide.UpdatedAt,
+		Summary:      location.Name,
+		Location:     location.Name,
+		Latitude:     location.Latitude,
+		Longitude:    location.Longitude,
+		HasGeo:       true,
+		LastModified: item.UpdatedAt,
+	}
+	if location.Address != "" {
+		event.Location += ", " + location.Address
+	}
+	// Website URLs aren't validated on input, so only well-formed web links are linked
+	if location.WebsiteURL != nil && ical.ValidURL(*location.WebsiteURL) {
+		event.URL = *location.WebsiteURL
+	}
+
+	var description []string
+	if item.Note != nil {
+		description = append(description, *item.Note)
+	}
+	if location.Description != nil {
+		description = append(description, *location.Description)
+	}
+	description = append(description, "From the guide \""+guide.Title+"\"")
+	event.Description = strings.Join(description, "\n\n")
+
+	switch {
+	case item.StartTime != nil:
+		start := clockMinutes(*item.StartTime)
+		duration := defaultVisitMinutes
+		if item.DurationMinutes != nil {
+			duration = *item.DurationMinutes
+		}
+		event.Start, event.End = at(start), at(start+duration)
+	case item.Slot != nil:
+		window := slotWindows[*item.Slot]
+		event.Start, event.End = at(window[0]), at(window[1])
+	default:
+		event.AllDay = true
+		event.Start = date
+		event.End = date.AddDate(0, 0, 1)
+	}
+	return event
+}
+
+// calendarFeedURL is the subscribable iCalendar feed for an itinerary, or ""
+// when the guide can't be opened without logging in
+func calendarFeedURL(c *fiber.Ctx, guide *models.Guide, shareToken string) string {
+	switch {
+	case shareToken != "":
+		return c.BaseURL() + "/api/v1/shared/" + shareToken + "/itinerary.ics"
+	case guide.IsPublic:
+		return c.BaseURL() + "/api/v1/guides/" + guide.ID.String() + "/itinerary.ics"
+	}
+	return ""
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CategoryRequest represents category create/update payload
+type CategoryRequest struct {
+	Slug        string     `json:"slug"`
+	DisplayName string     `json:"display_name"`
+	Icon        *string    `json:"icon"`
+	Color       *string    `json:"color"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	SortOrder   *int       `json:"sort_order"`
+}
+
+var (
+	categorySlugPattern  = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	categoryColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+)
+
+// GetCategories returns all categories in display order
+func GetCategories(c *fiber.Ctx) error {
+	var categories []models.Category
+	if err := database.DB.Order("sort_order ASC, display_name ASC").Find(&categories).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch categories",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"categories": categories,
+		"count":      len(categories),
+	})
+}
+
+// CreateCategory creates a new category (admin only)
+func CreateCategory(c *fiber.Ctx) error {
+	var req CategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Slug = strings.TrimSpace(req.Slug)
+	req.DisplayName = strings.TrimSpace(req.DisplayName)
+	if req.Slug == "" || req.DisplayName == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Slug and display name are required",
+		})
+	}
+	if !categorySlugPattern.MatchString(req.Slug) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Slug must be lowercase letters, numbers and dashes",
+		})
+	}
+
+	category := models.Category{
+		Slug:        req.Slug,
+		DisplayName: req.DisplayName,
+	}
+	if msg := applyCategoryRequest(&category, &req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var existing int64
+	database.DB.Model(&models.Category{}).Where("slug = ?", category.Slug).Count(&existing)
+	if existing > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "A category with this slug already exists",
+		})
+	}
+
+	if err := database.DB.Create(&category).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create category",
+		})
+	}
+	models.InvalidateCategoryCache()
+
+	return c.Status(fiber.StatusCreated).JSON(category)
+}
+
+// UpdateCategory updates a category (admin only). The slug cannot be changed
+// because locations reference it.
+func UpdateCategory(c *fiber.Ctx) error {
+	categoryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	var category models.Category
+	if err := database.DB.First(&category, categoryID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+
+	var req CategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Slug != "" && req.Slug != category.Slug {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Category slug cannot be changed",
+		})
+	}
+	if name := strings.TrimSpace(req.DisplayName); name != "" {
+		category.DisplayName = name
+	}
+	if msg := applyCategoryRequest(&category, &req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if err := database.DB.Save(&category).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update category",
+		})
+	}
+	models.InvalidateCategoryCache()
+
+	return c.JSON(category)
+}
+
+// DeleteCategory deletes a category that has no locations or subcategories (admin only)
+func DeleteCategory(c *fiber.Ctx) error {
+	categoryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	var category models.Category
+	if err := database.DB.First(&category, categoryID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+
+	var inUse int64
+	// Include trashed locations so they can still be restored
+	database.DB.Unscoped().Model(&models.Location{}).Where("category = ?", category.Slug).Count(&inUse)
+	if inUse > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Category is used by existing locations",
+		})
+	}
+
+	var children int64
+	database.DB.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children)
+	if children > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Category has subcategories",
+		})
+	}
+
+	if err := database.DB.Delete(&category).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete category",
+		})
+	}
+	models.InvalidateCategoryCache()
+
+	return c.JSON(fiber.Map{
+		"message": "Category deleted successfully",
+	})
+}
+
+// applyCategoryRequest copies optional fields onto the category and returns
+// a validation message, or "" if the request is valid
+func applyCategoryRequest(category *models.Category, req *CategoryRequest) string {
+	if req.Icon != nil {
+		category.Icon = strings.TrimSpace(*req.Icon)
+	}
+	if req.Color != nil {
+		if *req.Color != "" && !categoryColorPattern.MatchString(*req.Color) {
+			return "Color must be a hex value like #1A2B3C"
+		}
+		category.Color = *req.Color
+	}
+	if req.SortOrder != nil {
+		category.SortOrder = *req.SortOrder
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == uuid.Nil {
+			category.ParentID = nil
+			return ""
+		}
+		if category.ID != uuid.Nil && *req.ParentID == category.ID {
+			return "A category cannot be its own parent"
+		}
+		var parent models.Category
+		if err := database.DB.First(&parent, *req.ParentID).Error; err != nil {
+			return "Parent category not found"
+		}
+		if category.ID != uuid.Nil {
+			descendants, err := database.CategoryDescendantSlugs(category.Slug)
+			if err != nil {
+				return "Failed to validate parent category"
+			}
+			for _, slug := range descendants {
+				if slug == parent.Slug {
+					return "A category cannot be moved under its own subcategory"
+				}
+			}
+		}
+		category.ParentID = &parent.ID
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetCities returns all known cities, optionally filtered by region
+func GetCities(c *fiber.Ctx) error {
+	region := c.Query("region")
+
+	query := database.DB.Order("name ASC")
+	if region != "" {
+		query = query.Where("region ILIKE ?", region)
+	}
+
+	var cities []models.City
+	if err := query.Find(&cities).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch cities",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"cities": cities,
+		"count":  len(cities),
+	})
+}
+
+// GetCity returns a city by slug, name or alias
+func GetCity(c *fiber.Ctx) error {
+	city, err := database.FindCityByName(c.Params("slug"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch city",
+		})
+	}
+	if city == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "City not found",
+		})
+	}
+
+	return c.JSON(city)
+}
+
+// resolveCityFilter returns the city a request is scoped to. An empty name
+// selects the default city. A nil city with a nil error means the name is
+// not a known city and callers should fall back to free-text matching.
+func resolveCityFilter(name string) (*models.City, error) {
+	if name == "" {
+		return database.DefaultCity()
+	}
+	return database.FindCityByName(name)
+}
+
+// assignCity links a location to the city containing its coordinates and
+// replaces the free-text city with the canonical name
+func assignCity(location *models.Location) error {
+	city, err := database.FindCityForPoint(location.Latitude, location.Longitude)
+	if err != nil {
+		return err
+	}
+	if city == nil {
+		location.CityID = nil
+		location.City = models.NormalizeCityDisplay(location.City)
+		return nil
+	}
+	location.CityID = &city.ID
+	location.City = city.Name
+	return nil
+}
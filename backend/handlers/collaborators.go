@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InviteCollaboratorRequest represents collaborator invitation payload. The
+// invitee is named by username or by email; email invitations can be accepted
+// by any account that has verified that address.
+type InviteCollaboratorRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+// UpdateCollaboratorRequest represents collaborator role change payload
+type UpdateCollaboratorRequest struct {
+	Role string `json:"role"`
+}
+
+// GetGuideCollaborators returns a guide's collaborators and pending
+// invitations (owner and collaborators)
+func GetGuideCollaborators(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findGuideAs(c, userID, "Only the guide's collaborators can see who else has access",
+		models.GuideRoleOwner, models.GuideRoleEditor, models.GuideRoleViewer)
+	if err != nil {
+		return err
+	}
+
+	var collaborators []models.GuideCollaborator
+	err = database.DB.Preload("User").
+		Where("guide_id = ?", guide.ID).
+		Order("accepted_at IS NULL, created_at ASC").
+		Find(&collaborators).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch collaborators",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"collaborators": collaborators,
+		"count":         len(collaborators),
+	})
+}
+
+// InviteGuideCollaborator invites a user to view or edit a guide (owner only)
+func InviteGuideCollaborator(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findOwnGuide(c, userID)
+	if err != nil {
+		return err
+	}
+
+	var req InviteCollaboratorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	role := strings.ToLower(strings.TrimSpace(req.Role))
+	if role == "" {
+		role = models.GuideRoleViewer
+	}
+	if !models.IsValidCollaboratorRole(role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Role must be viewer or editor",
+		})
+	}
+
+	username := strings.TrimSpace(req.Username)
+	email := strings.TrimSpace(req.Email)
+	if (username == "") == (email == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invite someone by either username or email",
+		})
+	}
+
+	invite := models.GuideCollaborator{GuideID: guide.ID, Role: role, InvitedByID: userID}
+	if email != "" {
+		// Email invitations are never matched to an account here, so the owner
+		// can't learn who has signed up; they are claimed by whoever verifies
+		// the address
+		if !validEmail(email) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid email address",
+			})
+		}
+		lower := strings.ToLower(email)
+		invite.Email = &lower
+	} else {
+		var invitee models.User
+		err = database.DB.Select("id").Where("username = ?", username).First(&invitee).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		case err != nil:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to invite collaborator",
+			})
+		}
+		if invitee.ID == guide.UserID {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "You already own this guide",
+			})
+		}
+		invite.UserID = &invitee.ID
+	}
+
+	existing := database.DB.Model(&models.GuideCollaborator{}).Where("guide_id = ?", guide.ID)
+	if invite.UserID != nil {
+		existing = existing.Where("user_id = ?", *invite.UserID)
+	} else {
+		existing = existing.Where("email = ?", *invite.Email)
+	}
+	var count int64
+	existing.Count(&count)
+	if count > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "This person has already been invited",
+		})
+	}
+
+	if err := database.DB.Create(&invite).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to invite collaborator",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(invite)
+}
+
+// UpdateGuideCollaborator changes a collaborator's role (owner only)
+func UpdateGuideCollaborator(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findOwnGuide(c, userID)
+	if err != nil {
+		return err
+	}
+	collaborator, err := findGuideCollaborator(c, guide.ID)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateCollaboratorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	role := strings.ToLower(strings.TrimSpace(req.Role))
+	if !models.IsValidCollaboratorRole(role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Role must be viewer or editor",
+		})
+	}
+
+	collaborator.Role = role
+	if err := database.DB.Model(collaborator).Update("role", role).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update collaborator",
+		})
+	}
+
+	return c.JSON(collaborator)
+}
+
+// RemoveGuideCollaborator removes a collaborator or cancels an invitation.
+// The owner can remove anyone; collaborators can remove themselves.
+func RemoveGuideCollaborator(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid guide ID",
+		})
+	}
+
+	var guide models.Guide
+	if err := database.DB.First(&guide, guideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Guide not found",
+		})
+	}
+	collaborator, err := findGuideCollaborator(c, guide.ID)
+	if err != nil {
+		return err
+	}
+	self := collaborator.UserID != nil && *collaborator.UserID == userID
+	if guide.UserID != userID && !self {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the owner can remove other collaborators",
+		})
+	}
+
+	if err := database.DB.Delete(collaborator).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove collaborator",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Collaborator removed successfully",
+	})
+}
+
+// GetGuideInvitations returns the current user's pending guide invitations,
+// including those sent to their email before they had an account
+func GetGuideInvitations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	query, err := pendingInvitations(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch invitations",
+		})
+	}
+
+	var invitations []models.GuideCollaborator
+	if err := query.Preload("Guide.User").Order("created_at DESC").Find(&invitations).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch invitations",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"invitations": invitations,
+		"count":       len(invitations),
+	})
+}
+
+// AcceptGuideInvitation makes the current user a collaborator on the guide
+func AcceptGuideInvitation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	invitation, err := findInvitation(c, userID)
+	if err != nil {
+		return err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var guide models.Guide
+		if err := tx.Select("id", "user_id").First(&guide, invitation.GuideID).Error; err != nil {
+			return err
+		}
+		if guide.UserID == userID {
+			return fiber.NewError(fiber.StatusBadRequest, "You already own this guide")
+		}
+
+		// An email invitation for someone already on the guide is redundant
+		var count int64
+		tx.Model(&models.GuideCollaborator{}).
+			Where("guide_id = ? AND user_id = ? AND id <> ?", invitation.GuideID, userID, invitation.ID).
+			Count(&count)
+		if count > 0 {
+			return fiber.NewError(fiber.StatusConflict, "You have already been invited to this guide")
+		}
+
+		now := time.Now()
+		invitation.UserID = &userID
+		invitation.Email = nil
+		invitation.AcceptedAt = &now
+		return tx.Model(invitation).Updates(map[string]interface{}{
+			"user_id":     userID,
+			"email":       nil,
+			"accepted_at": now,
+		}).Error
+	})
+	if err != nil {
+		return guideItemError(err, "Failed to accept invitation")
+	}
+
+	return respondGuide(c, fiber.StatusOK, invitation.GuideID, userID)
+}
+
+// DeclineGuideInvitation deletes one of the current user's pending invitations
+func DeclineGuideInvitation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	invitation, err := findInvitation(c, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := database.DB.Delete(invitation).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to decline invitation",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Invitation declined",
+	})
+}
+
+// pendingInvitations scopes a query to invitations the user hasn't accepted
+// yet: those addressed to their account and, once they have verified their
+// email, those sent to that address
+func pendingInvitations(userID uuid.UUID) (*gorm.DB, error) {
+	var user models.User
+	if err := database.DB.Select("id", "email", "email_verified_at").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	if user.EmailVerifiedAt == nil {
+		return database.DB.Where("accepted_at IS NULL AND user_id = ?", userID), nil
+	}
+	return database.DB.Where(
+		"accepted_at IS NULL AND (user_id = ? OR (user_id IS NULL AND email = LOWER(?)))",
+		userID, user.Email,
+	), nil
+}
+
+// findInvitation loads the pending invitation named in the route for the user
+func findInvitation(c *fiber.Ctx, userID uuid.UUID) (*models.GuideCollaborator, error) {
+	inviteID, err := uuid.Parse(c.Params("inviteId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid invitation ID")
+	}
+
+	query, err := pendingInvitations(userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch invitation")
+	}
+	var invitation models.GuideCollaborator
+	if err := query.First(&invitation, inviteID).Error; err != nil {
+		if unverifiedEmailInvitation(userID, inviteID) {
+			return nil, fiber.NewError(fiber.StatusForbidden, "Verify your email address to accept this invitation")
+		}
+		return nil, fiber.NewError(fiber.StatusNotFound, "Invitation not found")
+	}
+	return &invitation, nil
+}
+
+// unverifiedEmailInvitation reports whether the invitation was sent to the
+// user's email address, which they haven't verified yet
+func unverifiedEmailInvitation(userID, inviteID uuid.UUID) bool {
+	var count int64
+	database.DB.Model(&models.GuideCollaborator{}).
+		Joins("JOIN users ON users.id = ? AND users.email_verified_at IS NULL AND LOWER(users.email) = guide_collaborators.email", userID).
+		Where("guide_collaborators.id = ? AND guide_collaborators.user_id IS NULL AND guide_collaborators.accepted_at IS NULL", inviteID).
+		Count(&count)
+	return count > 0
+}
+
+// validEmail is a loose check that an address has a local part and a domain
+func validEmail(email string) bool {
+	at := strings.LastIndex(email, "@")
+	return at > 0 && at < len(email)-1 && !strings.ContainsAny(email, " \t\r\n<>,;")
+}
+
+// findGuideCollaborator loads the collaborator named in the route from the given guide
+func findGuideCollaborator(c *fiber.Ctx, guideID uuid.UUID) (*models.GuideCollaborator, error) {
+	collaboratorID, err := uuid.Parse(c.Params("collaboratorId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid collaborator ID")
+	}
+
+	var collaborator models.GuideCollaborator
+	if err := database.DB.Preload("User").Where("guide_id = ?", guideID).First(&collaborator, collaboratorID).Error; err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Collaborator not found")
+	}
+	return &collaborator, nil
+}
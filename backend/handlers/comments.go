@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"strings"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GuideCommentRequest represents comment create payload. Name is required
+// unless the commenter is signed in.
+type GuideCommentRequest struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+// GetSharedGuideComments lists a guide's comments, newest first, to anyone
+// holding an active share link
+func GetSharedGuideComments(c *fiber.Ctx) error {
+	guide, _, err := findSharedGuide(c)
+	if err != nil {
+		return err
+	}
+	return respondGuideComments(c, guide.ID)
+}
+
+// CreateSharedGuideComment adds a comment through a share link that allows
+// commenting. Read-only links are refused.
+func CreateSharedGuideComment(c *fiber.Ctx) error {
+	guide, share, err := findSharedGuide(c)
+	if err != nil {
+		return err
+	}
+	if !share.CanComment() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "This share link is read-only",
+		})
+	}
+
+	var req GuideCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	comment := models.GuideComment{GuideID: guide.ID, ShareID: share.ID, UserID: optionalUserID(c)}
+	comment.Text = strings.TrimSpace(req.Text)
+	comment.AuthorName = strings.TrimSpace(req.Name)
+	if comment.AuthorName == "" && comment.UserID != nil {
+		var user models.User
+		if err := database.DB.Select("display_name").First(&user, *comment.UserID).Error; err == nil {
+			comment.AuthorName = user.DisplayName
+		}
+	}
+	if err := checkGuideComment(&comment); err != nil {
+		return err
+	}
+
+	if err := database.DB.Create(&comment).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to add comment",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(comment)
+}
+
+// GetGuideComments lists the comments left on a guide through its share links
+// (owner and collaborators)
+func GetGuideComments(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findGuideAs(c, userID, "Only the guide's collaborators can see its comments",
+		models.GuideRoleOwner, models.GuideRoleEditor, models.GuideRoleViewer)
+	if err != nil {
+		return err
+	}
+	return respondGuideComments(c, guide.ID)
+}
+
+// DeleteGuideComment removes a comment from a guide (owner only)
+func DeleteGuideComment(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findOwnGuide(c, userID)
+	if err != nil {
+		return err
+	}
+	commentID, err := uuid.Parse(c.Params("commentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid comment ID",
+		})
+	}
+
+	result := database.DB.Where("guide_id = ?", guide.ID).Delete(&models.GuideComment{}, commentID)
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete comment",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Comment not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Comment deleted successfully",
+	})
+}
+
+// checkGuideComment validates a comment's text and author name
+func checkGuideComment(comment *models.GuideComment) error {
+	switch {
+	case comment.Text == "":
+		return fiber.NewError(fiber.StatusBadRequest, "Comment text is required")
+	case len([]rune(comment.Text)) > models.MaxCommentLength:
+		return fiber.NewError(fiber.StatusBadRequest, "Comment is too long")
+	case comment.AuthorName == "":
+		return fiber.NewError(fiber.StatusBadRequest, "Name is required")
+	case len([]rune(comment.AuthorName)) > models.MaxCommentAuthorName:
+		return fiber.NewError(fiber.StatusBadRequest, "Name is too long")
+	}
+	return nil
+}
+
+// respondGuideComments writes a page of a guide's comments, newest first
+func respondGuideComments(c *fiber.Ctx, guideID uuid.UUID) error {
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var total int64
+	var comments []models.GuideComment
+	query := database.DB.Model(&models.GuideComment{}).Where("guide_id = ?", guideID).Session(&gorm.Session{})
+	err := query.Count(&total).Error
+	if err == nil {
+		err = query.Order("created_at DESC, id").Offset((page - 1) * limit).Limit(limit).Find(&comments).Error
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch comments",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"comments": comments,
+		"count":    len(comments),
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCheckGuideComment(t *testing.T) {
+	tests := []struct {
+		name, text string
+		want       string
+	}{
+		{"Sam", "Love the second stop", ""},
+		{"Sam", "", "Comment text is required"},
+		{"", "Love it", "Name is required"},
+		{"Sam", strings.Repeat("a", models.MaxCommentLength+1), "Comment is too long"},
+		{strings.Repeat("é", models.MaxCommentAuthorName), "Love it", ""},
+		{strings.Repeat("é", models.MaxCommentAuthorName+1), "Love it", "Name is too long"},
+	}
+	for _, tt := range tests {
+		err := checkGuideComment(&models.GuideComment{AuthorName: tt.name, Text: tt.text})
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%q/%q: unexpected error %v", tt.name, tt.text, err)
+			}
+			continue
+		}
+		var fiberErr *fiber.Error
+		if !errors.As(err, &fiberErr) || fiberErr.Code != fiber.StatusBadRequest || fiberErr.Message != tt.want {
+			t.Errorf("%q/%q: error %v, want 400 %q", tt.name, tt.text, err, tt.want)
+		}
+	}
+}
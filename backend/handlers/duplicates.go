@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"myarea-backend/database"
+	"myarea-backend/geo"
+	"myarea-backend/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// duplicateRadiusMeters is how close two pins must be to be compared by name
+	duplicateRadiusMeters = 150.0
+	// duplicateNameSimilarity is the minimum name similarity (0-1) for a duplicate
+	duplicateNameSimilarity = 0.6
+	// duplicateLimit caps how many candidates are reported
+	duplicateLimit = 5
+)
+
+// DuplicateCandidate is an existing location that looks like the one being created
+type DuplicateCandidate struct {
+	Location   models.Location `json:"location"`
+	Distance   float64         `json:"distance_meters"`
+	Similarity float64         `json:"name_similarity"`
+}
+
+// findDuplicateLocations returns locations listed to the viewer near lat/lng
+// with a similar name. Unlisted places are left out since they can only be
+// found by someone who already has the link, and owners are not loaded so the
+// response doesn't expose other users' accounts.
+func findDuplicateLocations(name string, lat, lng float64, viewerID *uuid.UUID) ([]DuplicateCandidate, error) {
+	// Degrees of latitude/longitude covering the search radius
+	dLat := duplicateRadiusMeters / 111320.0
+	dLng := dLat / math.Max(math.Cos(lat*math.Pi/180), 0.01)
+
+	var nearby []models.Location
+	err := visibleLocations(database.DB, viewerID).
+		Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", lat-dLat, lat+dLat, lng-dLng, lng+dLng).
+		Find(&nearby).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []DuplicateCandidate
+	for _, location := range nearby {
+		distance := geo.DistanceMeters(lat, lng, location.Latitude, location.Longitude)
+		if distance > duplicateRadiusMeters {
+			continue
+		}
+		similarity := nameSimilarity(name, location.Name)
+		if similarity < duplicateNameSimilarity {
+			continue
+		}
+		candidates = append(candidates, DuplicateCandidate{
+			Location:   location,
+			Distance:   math.Round(distance*10) / 10,
+			Similarity: math.Round(similarity*100) / 100,
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Similarity != candidates[j].Similarity {
+			return candidates[i].Similarity > candidates[j].Similarity
+		}
+		return candidates[i].Distance < candidates[j].Distance
+	})
+	if len(candidates) > duplicateLimit {
+		candidates = candidates[:duplicateLimit]
+	}
+	return candidates, nil
+}
+
+// duplicateWarnings converts candidates into location warnings
+func duplicateWarnings(candidates []DuplicateCandidate) []LocationWarning {
+	warnings := make([]LocationWarning, 0, len(candidates))
+	for _, candidate := range candidates {
+		id := candidate.Location.ID
+		warnings = append(warnings, LocationWarning{
+			Code:       "possible_duplicate",
+			Message:    fmt.Sprintf("%q is %.0f m away", candidate.Location.Name, candidate.Distance),
+			LocationID: &id,
+		})
+	}
+	return warnings
+}
+
+// nameSimilarity scores two place names from 0 to 1, taking the better of an
+// edit-distance ratio and word overlap so "Tartine" matches "Tartine Bakery"
+func nameSimilarity(a, b string) float64 {
+	wordsA := nameWords(a)
+	wordsB := nameWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	joinedA := strings.Join(wordsA, " ")
+	joinedB := strings.Join(wordsB, " ")
+	longest := math.Max(float64(len([]rune(joinedA))), float64(len([]rune(joinedB))))
+	editRatio := 1 - float64(levenshtein(joinedA, joinedB))/longest
+
+	setB := make(map[string]bool, len(wordsB))
+	for _, w := range wordsB {
+		setB[w] = true
+	}
+	shared := 0
+	for _, w := range wordsA {
+		if setB[w] {
+			shared++
+		}
+	}
+	// Overlap relative to the shorter name
+	overlap := float64(shared) / math.Min(float64(len(wordsA)), float64(len(wordsB)))
+
+	return math.Max(editRatio, overlap)
+}
+
+// nameWords splits a name into normalized words, dropping filler words
+func nameWords(name string) []string {
+	var words []string
+	for _, w := range models.FoldWords(name) {
+		switch w {
+		case "the", "a", "an", "and", "of":
+			continue
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"myarea-backend/geofile"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// exportFormat returns the file format requested with ?format= or, failing
+// that, the Accept header. An empty format means the regular JSON response.
+// Either way the response depends on Accept, so caches are told.
+func exportFormat(c *fiber.Ctx) (geofile.Format, error) {
+	c.Vary(fiber.HeaderAccept)
+	if name := c.Query("format"); name != "" {
+		if strings.EqualFold(name, "json") {
+			return "", nil
+		}
+		return geofile.ParseFormat(name)
+	}
+	for _, mediaType := range strings.Split(c.Get(fiber.HeaderAccept), ",") {
+		if format, ok := geofile.FormatForMediaType(mediaType); ok {
+			return format, nil
+		}
+	}
+	return "", nil
+}
+
+// sendLocationsExport writes locations as a downloadable file
+func sendLocationsExport(c *fiber.Ctx, format geofile.Format, title string, locations []models.Location) error {
+	places := make([]geofile.Place, 0, len(locations))
+	for i := range locations {
+		places = append(places, locationToPlace(&locations[i]))
+	}
+
+	var buf bytes.Buffer
+	if err := geofile.Write(format, &buf, title, places); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export locations",
+		})
+	}
+
+	filename := models.NormalizeTag(title)
+	if filename == "" {
+		filename = "locations"
+	}
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format.Extension()))
+	return c.Send(buf.Bytes())
+}
+
+// locationToPlace converts a location for export
+func locationToPlace(l *models.Location) geofile.Place {
+	place := geofile.Place{
+		ID:         l.ID.String(),
+		Name:       l.Name,
+		Category:   l.Category,
+		Address:    l.Address,
+		City:       l.City,
+		Latitude:   l.Latitude,
+		Longitude:  l.Longitude,
+		Tags:       l.Tags,
+		Rating:     l.Rating,
+		PriceLevel: l.PriceLevel,
+		Author:     l.User.Username,
+		UpdatedAt:  l.UpdatedAt,
+	}
+	if l.Description != nil {
+		place.Description = *l.Description
+	}
+	if l.WebsiteURL != nil {
+		place.WebsiteURL = *l.WebsiteURL
+	}
+	if l.ImageURL != nil {
+		place.ImageURL = *l.ImageURL
+	}
+	return place
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"myarea-backend/geofile"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestExportFormat(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		format, err := exportFormat(c)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return c.SendString(string(format))
+	})
+
+	tests := []struct {
+		query  string
+		accept string
+		status int
+		want   geofile.Format
+	}{
+		{"", "", fiber.StatusOK, ""},
+		{"", "application/json", fiber.StatusOK, ""},
+		{"", "text/html, text/csv;q=0.9", fiber.StatusOK, geofile.FormatCSV},
+		{"?format=kml", "text/csv", fiber.StatusOK, geofile.FormatKML},
+		{"?format=json", "text/csv", fiber.StatusOK, ""},
+		{"?format=xlsx", "", fiber.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/"+tt.query, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s Accept %q: status %d, want %d", tt.query, tt.accept, resp.StatusCode, tt.status)
+			continue
+		}
+		// The JSON response varies by Accept just like the file downloads
+		if vary := resp.Header.Get("Vary"); vary != "Accept" {
+			t.Errorf("%s Accept %q: Vary = %q", tt.query, tt.accept, vary)
+		}
+		if tt.status != fiber.StatusOK {
+			continue
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if got := geofile.Format(body); got != tt.want {
+			t.Errorf("%s Accept %q: format %q, want %q", tt.query, tt.accept, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"strings"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ForkGuideRequest represents guide fork payload. Forks are private unless
+// IsPublic says otherwise.
+type ForkGuideRequest struct {
+	Title    string `json:"title"`
+	IsPublic *bool  `json:"is_public"`
+}
+
+// CopyLocationRequest represents location copy payload. Copies are private
+// unless Visibility says otherwise.
+type CopyLocationRequest struct {
+	Visibility string `json:"visibility"`
+}
+
+// ForkGuide copies a guide the caller can see into a new guide they own,
+// with its stops, notes and plan. Stops the caller can't open are left out.
+func ForkGuide(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid guide ID",
+		})
+	}
+
+	var source models.Guide
+	if err := visibleGuides(database.DB, &userID).First(&source, guideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Guide not found",
+		})
+	}
+
+	var req ForkGuideRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	fork := models.Guide{
+		UserID:           userID,
+		Title:            source.Title,
+		Description:      source.Description,
+		City:             source.City,
+		CityID:           source.CityID,
+		StartDate:        source.StartDate,
+		EndDate:          source.EndDate,
+		ForkedFromID:     &source.ID,
+		ForkedFromUserID: &source.UserID,
+	}
+	if title := strings.TrimSpace(req.Title); title != "" {
+		fork.Title = title
+	}
+	if req.IsPublic != nil {
+		fork.IsPublic = *req.IsPublic
+	}
+
+	var items []models.GuideItem
+	if err := database.DB.Where("guide_id = ?", source.ID).Order("position ASC, created_at ASC").Find(&items).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fork guide",
+		})
+	}
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		ids[i] = item.LocationID
+	}
+	var viewable []uuid.UUID
+	if len(ids) > 0 {
+		err := viewableLocations(database.DB.Model(&models.Location{}), &userID).
+			Where("locations.id IN ?", ids).
+			Pluck("locations.id", &viewable).Error
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fork guide",
+			})
+		}
+	}
+	canView := make(map[uuid.UUID]bool, len(viewable))
+	for _, id := range viewable {
+		canView[id] = true
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&fork).Error; err != nil {
+			return err
+		}
+		// Create skips false booleans in favour of the column default
+		if !fork.IsPublic {
+			if err := tx.Model(&fork).Update("is_public", false).Error; err != nil {
+				return err
+			}
+		}
+
+		position := 0
+		for _, item := range items {
+			if !canView[item.LocationID] {
+				continue
+			}
+			copied := models.GuideItem{
+				GuideID:         fork.ID,
+				LocationID:      item.LocationID,
+				Position:        position,
+				Note:            item.Note,
+				Day:             item.Day,
+				Slot:            item.Slot,
+				StartTime:       item.StartTime,
+				DurationMinutes: item.DurationMinutes,
+			}
+			if err := tx.Create(&copied).Error; err != nil {
+				return err
+			}
+			position++
+		}
+		return database.RefreshGuideForkCount(tx, source.ID)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fork guide",
+		})
+	}
+
+	return respondGuide(c, fiber.StatusCreated, fork.ID, userID)
+}
+
+// GetGuideForks returns the forks of a guide that the caller can see
+func GetGuideForks(c *fiber.Ctx) error {
+	guideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid guide ID",
+		})
+	}
+
+	viewerID := optionalUserID(c)
+	var guide models.Guide
+	if err := visibleGuides(database.DB, viewerID).First(&guide, guideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Guide not found",
+		})
+	}
+
+	var forks []models.Guide
+	err = visibleGuides(database.DB.Preload("User"), viewerID).
+		Where("forked_from_id = ?", guide.ID).
+		Order("created_at DESC").
+		Find(&forks).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch forks",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"forks":      forks,
+		"count":      len(forks),
+		"fork_count": guide.ForkCount,
+	})
+}
+
+// CopyLocation copies another user's location into the caller's own
+// collection, crediting the original
+func CopyLocation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	source, err := findViewableLocation(c)
+	if err != nil {
+		return err
+	}
+	if source.UserID == userID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "This location is already yours",
+		})
+	}
+
+	var req CopyLocationRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+	visibility := models.VisibilityPrivate
+	if req.Visibility != "" {
+		if !models.IsValidVisibility(req.Visibility) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Visibility must be public, unlisted, friends or private",
+			})
+		}
+		visibility = req.Visibility
+	}
+
+	// The copy is the caller's to rate and illustrate; photos stay with the original
+	location := models.Location{
+		UserID:           userID,
+		Name:             source.Name,
+		Description:      source.Description,
+		Category:         source.Category,
+		Address:          source.Address,
+		Latitude:         source.Latitude,
+		Longitude:        source.Longitude,
+		City:             source.City,
+		CityID:           source.CityID,
+		PriceLevel:       source.PriceLevel,
+		Tags:             append([]string{}, source.Tags...),
+		ImageURL:         source.ImageURL,
+		WebsiteURL:       source.WebsiteURL,
+		Visibility:       visibility,
+		OpeningHours:     source.OpeningHours,
+		CopiedFromID:     &source.ID,
+		CopiedFromUserID: &source.UserID,
+	}
+	if err := saveNewLocation(&location, userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to copy location",
+		})
+	}
+
+	database.DB.Preload("User").First(&location, location.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(LocationResponse{Location: location})
+}
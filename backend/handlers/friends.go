@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AddFriendRequest represents add friend payload
+type AddFriendRequest struct {
+	Username string `json:"username"`
+}
+
+// GetFriends returns the users the current user shares friends-only locations with
+func GetFriends(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var friendships []models.Friendship
+	if err := database.DB.Preload("Friend").Where("user_id = ?", userID).Order("created_at DESC").Find(&friendships).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch friends",
+		})
+	}
+
+	friends := make([]models.User, 0, len(friendships))
+	for _, f := range friendships {
+		friends = append(friends, f.Friend)
+	}
+
+	return c.JSON(fiber.Map{
+		"friends": friends,
+		"count":   len(friends),
+	})
+}
+
+// AddFriend shares the current user's friends-only locations with another user
+func AddFriend(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req AddFriendRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var friend models.User
+	if err := database.DB.Where("username = ?", req.Username).First(&friend).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	if friend.ID == userID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "You cannot add yourself as a friend",
+		})
+	}
+
+	friendship := models.Friendship{UserID: userID, FriendID: friend.ID}
+	if err := database.DB.FirstOrCreate(&friendship, friendship).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to add friend",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(friend)
+}
+
+// RemoveFriend stops sharing friends-only locations with a user
+func RemoveFriend(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var friend models.User
+	if err := database.DB.Where("username = ?", c.Params("username")).First(&friend).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	result := database.DB.Where("user_id = ? AND friend_id = ?", userID, friend.ID).Delete(&models.Friendship{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove friend",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Friend not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Friend removed successfully",
+	})
+}
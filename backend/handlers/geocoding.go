@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"myarea-backend/geo"
+)
+
+// geocodeMismatchMeters is how far the typed coordinates may be from the
+// geocoded address before the location is flagged
+const geocodeMismatchMeters = 1000.0
+
+// geocodeTimeout bounds the time spent on geocoding during a request
+const geocodeTimeout = 8 * time.Second
+
+// resolveLocationGeography fills in missing coordinates, address and city on
+// req using the configured geocoder, and flags coordinates that disagree with
+// the address. The returned error message is safe to show to the client.
+func resolveLocationGeography(ctx context.Context, req *CreateLocationRequest) ([]LocationWarning, error) {
+	var warnings []LocationWarning
+	req.Address = strings.TrimSpace(req.Address)
+	req.City = strings.TrimSpace(req.City)
+	hasCoords := req.Latitude != nil && req.Longitude != nil
+
+	if hasCoords && !geo.ValidCoordinates(*req.Latitude, *req.Longitude) {
+		return nil, errors.New("Coordinates are out of range")
+	}
+
+	geocoder := geo.DefaultGeocoder
+	if geocoder == nil {
+		if !hasCoords || req.Address == "" || req.City == "" {
+			return nil, errors.New("Address, city, latitude and longitude are required")
+		}
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, geocodeTimeout)
+	defer cancel()
+
+	switch {
+	case !hasCoords && req.Address == "":
+		return nil, errors.New("An address or coordinates are required")
+
+	case !hasCoords:
+		// Fill in coordinates from the address
+		place, err := geocoder.Geocode(ctx, req.Address)
+		if err != nil {
+			return nil, errors.New("Could not find coordinates for this address")
+		}
+		req.Latitude = &place.Latitude
+		req.Longitude = &place.Longitude
+		if req.City == "" {
+			req.City = place.City
+		}
+
+	case req.Address != "":
+		// Both were given; check they roughly agree
+		place, err := geocoder.Geocode(ctx, req.Address)
+		if err != nil {
+			if !errors.Is(err, geo.ErrNotFound) {
+				log.Printf("Geocoding %q failed: %v", req.Address, err)
+			}
+			break
+		}
+		distance := geo.DistanceMeters(*req.Latitude, *req.Longitude, place.Latitude, place.Longitude)
+		if distance > geocodeMismatchMeters {
+			warnings = append(warnings, LocationWarning{
+				Code:    "address_mismatch",
+				Message: fmt.Sprintf("The coordinates are %.1f km from the address", distance/1000),
+			})
+		}
+	}
+
+	if req.City == "" || req.Address == "" {
+		// Normalize city (and address, if missing) from the coordinates
+		place, err := geocoder.Reverse(ctx, *req.Latitude, *req.Longitude)
+		if err == nil {
+			if req.City == "" {
+				req.City = place.City
+			}
+			if req.Address == "" {
+				req.Address = place.Address
+			}
+		} else if !errors.Is(err, geo.ErrNotFound) {
+			log.Printf("Reverse geocoding (%f, %f) failed: %v", *req.Latitude, *req.Longitude, err)
+		}
+	}
+
+	if req.City == "" || req.Address == "" {
+		return nil, errors.New("Could not determine the address and city for these coordinates")
+	}
+
+	return warnings, nil
+}
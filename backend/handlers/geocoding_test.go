@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"myarea-backend/geo"
+)
+
+func withFixtureGeocoder(t *testing.T) {
+	t.Helper()
+	fixture := geo.NewFixtureGeocoder()
+	fixture.Add(geo.Place{Latitude: 48.8584, Longitude: 2.2945, Address: "Champ de Mars, Paris", City: "Paris"})
+	previous := geo.DefaultGeocoder
+	geo.DefaultGeocoder = fixture
+	t.Cleanup(func() { geo.DefaultGeocoder = previous })
+}
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func TestResolveLocationGeographyFromAddress(t *testing.T) {
+	withFixtureGeocoder(t)
+
+	req := CreateLocationRequest{Address: "champ de mars, paris"}
+	warnings, err := resolveLocationGeography(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("resolveLocationGeography: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings %+v", warnings)
+	}
+	if req.Latitude == nil || *req.Latitude != 48.8584 || *req.Longitude != 2.2945 {
+		t.Errorf("coordinates not filled in: %v, %v", req.Latitude, req.Longitude)
+	}
+	if req.City != "Paris" {
+		t.Errorf("City = %q, want Paris", req.City)
+	}
+}
+
+func TestResolveLocationGeographyFromCoordinates(t *testing.T) {
+	withFixtureGeocoder(t)
+
+	req := CreateLocationRequest{Latitude: float(48.8590), Longitude: float(2.2950)}
+	if _, err := resolveLocationGeography(context.Background(), &req); err != nil {
+		t.Fatalf("resolveLocationGeography: %v", err)
+	}
+	if req.Address != "Champ de Mars, Paris" || req.City != "Paris" {
+		t.Errorf("reverse geocoding filled in %q, %q", req.Address, req.City)
+	}
+}
+
+func TestResolveLocationGeographyMismatch(t *testing.T) {
+	withFixtureGeocoder(t)
+
+	// Montmartre is several kilometres from the address
+	req := CreateLocationRequest{Address: "Champ de Mars, Paris", City: "Paris", Latitude: float(48.8867), Longitude: float(2.3431)}
+	warnings, err := resolveLocationGeography(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("resolveLocationGeography: %v", err)
+	}
+	if len(warnings) != 1 || warnings[0].Code != "address_mismatch" {
+		t.Errorf("warnings = %+v, want one address_mismatch", warnings)
+	}
+}
+
+func TestResolveLocationGeographyErrors(t *testing.T) {
+	withFixtureGeocoder(t)
+
+	tests := []CreateLocationRequest{
+		{},
+		{Address: "1 Unknown Street"},
+		{Latitude: float(91), Longitude: float(0), Address: "x", City: "y"},
+		// Nothing nearby to reverse geocode
+		{Latitude: float(-33.8688), Longitude: float(151.2093)},
+	}
+	for i, req := range tests {
+		if _, err := resolveLocationGeography(context.Background(), &req); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+}
+
+func TestResolveLocationGeographyWithoutGeocoder(t *testing.T) {
+	previous := geo.DefaultGeocoder
+	geo.DefaultGeocoder = nil
+	defer func() { geo.DefaultGeocoder = previous }()
+
+	req := CreateLocationRequest{Address: "Champ de Mars, Paris"}
+	if _, err := resolveLocationGeography(context.Background(), &req); err == nil {
+		t.Error("expected coordinates and city to be required")
+	}
+
+	req = CreateLocationRequest{Address: "Champ de Mars", City: "Paris", Latitude: float(48.8584), Longitude: float(2.2945)}
+	if _, err := resolveLocationGeography(context.Background(), &req); err != nil {
+		t.Errorf("complete request rejected: %v", err)
+	}
+}
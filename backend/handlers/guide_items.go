@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GuideItemRequest represents guide item create and update payload. Day and
+// DurationMinutes are cleared by sending 0, Slot and StartTime by sending "".
+type GuideItemRequest struct {
+	LocationID      *uuid.UUID `json:"location_id"`
+	Position        *int       `json:"position"`
+	Note            *string    `json:"note"`
+	Day             *int       `json:"day"`
+	Slot            *string    `json:"slot"`
+	StartTime       *string    `json:"start_time"`
+	DurationMinutes *int       `json:"duration_minutes"`
+}
+
+// ReorderGuideItemsRequest represents guide item reorder payload
+type ReorderGuideItemsRequest struct {
+	ItemIDs []uuid.UUID `json:"item_ids"`
+}
+
+// AddGuideItem adds a location to a guide, at the end or at the given position (owner or editors)
+func AddGuideItem(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findEditableGuide(c, userID)
+	if err != nil {
+		return err
+	}
+	version, err := editedGuideVersion(c, guide)
+	if err != nil {
+		return err
+	}
+
+	var req GuideItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.LocationID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Location ID is required",
+		})
+	}
+	if err := checkGuideLocations(guide.UserID, *req.LocationID); err != nil {
+		return err
+	}
+	if guide.UserID != userID {
+		// Editors can only add places they can open themselves
+		if err := checkGuideLocations(userID, *req.LocationID); err != nil {
+			return err
+		}
+	}
+
+	item := models.GuideItem{GuideID: guide.ID, LocationID: *req.LocationID}
+	if err := applyGuideItemRequest(guide, &item, &req); err != nil {
+		return err
+	}
+
+	err = withLockedGuide(guide.ID, version, func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.GuideItem{}).Where("guide_id = ?", guide.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= models.MaxGuideItems {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("A guide can have at most %d stops", models.MaxGuideItems))
+		}
+		var existing int64
+		tx.Model(&models.GuideItem{}).Where("guide_id = ? AND location_id = ?", guide.ID, item.LocationID).Count(&existing)
+		if existing > 0 {
+			return fiber.NewError(fiber.StatusConflict, "This location is already in the guide")
+		}
+
+		item.Position = int(count)
+		if req.Position != nil && *req.Position >= 0 && *req.Position < int(count) {
+			item.Position = *req.Position
+			err := tx.Model(&models.GuideItem{}).
+				Where("guide_id = ? AND position >= ?", guide.ID, item.Position).
+				Update("position", gorm.Expr("position + 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Create(&item).Error
+	})
+	if err != nil {
+		return guideItemError(err, "Failed to add location to guide")
+	}
+
+	return respondGuide(c, fiber.StatusCreated, guide.ID, userID)
+}
+
+// UpdateGuideItem edits a stop's note, day, time slot or suggested duration (owner or editors)
+func UpdateGuideItem(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findEditableGuide(c, userID)
+	if err != nil {
+		return err
+	}
+	version, err := editedGuideVersion(c, guide)
+	if err != nil {
+		return err
+	}
+	item, err := findGuideItem(c, guide.ID)
+	if err != nil {
+		return err
+	}
+
+	var req GuideItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := applyGuideItemRequest(guide, item, &req); err != nil {
+		return err
+	}
+
+	// Only write the fields this request edits, so a reorder committed since
+	// the item was loaded keeps its position
+	err = withLockedGuide(guide.ID, version, func(tx *gorm.DB) error {
+		return tx.Model(item).Select("note", "day", "slot", "start_time", "duration_minutes").Updates(item).Error
+	})
+	if err != nil {
+		return guideItemError(err, "Failed to update guide stop")
+	}
+
+	return respondGuide(c, fiber.StatusOK, guide.ID, userID)
+}
+
+// RemoveGuideItem removes a stop from a guide (owner or editors)
+func RemoveGuideItem(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findEditableGuide(c, userID)
+	if err != nil {
+		return err
+	}
+	version, err := editedGuideVersion(c, guide)
+	if err != nil {
+		return err
+	}
+	item, err := findGuideItem(c, guide.ID)
+	if err != nil {
+		return err
+	}
+
+	err = withLockedGuide(guide.ID, version, func(tx *gorm.DB) error {
+		if err := tx.Delete(item).Error; err != nil {
+			return err
+		}
+		return database.CompactGuideItems(tx, guide.ID)
+	})
+	if err != nil {
+		return guideItemError(err, "Failed to remove guide stop")
+	}
+
+	return respondGuide(c, fiber.StatusOK, guide.ID, userID)
+}
+
+// ReorderGuideItems sets the order of a guide's stops. The request must list
+// every item exactly once (owner or editors).
+func ReorderGuideItems(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findEditableGuide(c, userID)
+	if err != nil {
+		return err
+	}
+	version, err := editedGuideVersion(c, guide)
+	if err != nil {
+		return err
+	}
+
+	var req ReorderGuideItemsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	err = withLockedGuide(guide.ID, version, func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		if err := tx.Model(&models.GuideItem{}).Where("guide_id = ?", guide.ID).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if !samePermutation(ids, req.ItemIDs) {
+			return fiber.NewError(fiber.StatusBadRequest, "Item IDs must list every stop in the guide exactly once")
+		}
+		for position, id := range req.ItemIDs {
+			if err := tx.Model(&models.GuideItem{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return guideItemError(err, "Failed to reorder guide")
+	}
+
+	return respondGuide(c, fiber.StatusOK, guide.ID, userID)
+}
+
+// replaceGuideItems makes the guide's stops exactly locationIDs, in order.
+// Items for locations that stay keep their notes and planning details.
+func replaceGuideItems(tx *gorm.DB, guideID uuid.UUID, locationIDs []uuid.UUID) error {
+	var items []models.GuideItem
+	if err := tx.Where("guide_id = ?", guideID).Find(&items).Error; err != nil {
+		return err
+	}
+
+	keep := make(map[uuid.UUID]bool, len(locationIDs))
+	for _, id := range locationIDs {
+		keep[id] = true
+	}
+	existing := make(map[uuid.UUID]*models.GuideItem, len(items))
+	for i := range items {
+		if !keep[items[i].LocationID] {
+			if err := tx.Delete(&items[i]).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		existing[items[i].LocationID] = &items[i]
+	}
+
+	for position, locationID := range locationIDs {
+		if item, ok := existing[locationID]; ok {
+			if item.Position != position {
+				if err := tx.Model(item).Update("position", position).Error; err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		item := models.GuideItem{GuideID: guideID, LocationID: locationID, Position: position}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyGuideItemRequest validates the request and copies its planning fields onto item
+func applyGuideItemRequest(guide *models.Guide, item *models.GuideItem, req *GuideItemRequest) error {
+	if req.Note != nil {
+		note := strings.TrimSpace(*req.Note)
+		if len([]rune(note)) > models.MaxGuideItemNote {
+			return fiber.NewError(fiber.StatusBadRequest, "Note is too long")
+		}
+		if note == "" {
+			item.Note = nil
+		} else {
+			item.Note = &note
+		}
+	}
+	if req.Day != nil {
+		switch {
+		case *req.Day < 0:
+			return fiber.NewError(fiber.StatusBadRequest, "Day must be 1 or later")
+		case *req.Day == 0:
+			item.Day = nil
+		case guide.TripDays() > 0 && *req.Day > guide.TripDays():
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Day must be within the %d-day trip", guide.TripDays()))
+		case *req.Day > models.MaxTripDays:
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Day must be at most %d", models.MaxTripDays))
+		default:
+			day := *req.Day
+			item.Day = &day
+		}
+	}
+	if req.Slot != nil {
+		slot := strings.ToLower(strings.TrimSpace(*req.Slot))
+		switch {
+		case slot == "":
+			item.Slot = nil
+		case slotWindows[slot] == [2]int{}:
+			return fiber.NewError(fiber.StatusBadRequest, "Slot must be morning, afternoon, evening or night")
+		default:
+			item.Slot = &slot
+		}
+	}
+	if req.StartTime != nil {
+		switch start := strings.TrimSpace(*req.StartTime); start {
+		case "":
+			item.StartTime = nil
+		default:
+			parsed, err := time.Parse("15:04", start)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Start time must be formatted as HH:MM")
+			}
+			formatted := parsed.Format("15:04")
+			item.StartTime = &formatted
+		}
+	}
+	if req.DurationMinutes != nil {
+		switch {
+		case *req.DurationMinutes < 0 || *req.DurationMinutes > 24*60:
+			return fiber.NewError(fiber.StatusBadRequest, "Duration must be between 1 and 1440 minutes")
+		case *req.DurationMinutes == 0:
+			item.DurationMinutes = nil
+		default:
+			minutes := *req.DurationMinutes
+			item.DurationMinutes = &minutes
+		}
+	}
+	return nil
+}
+
+// withLockedGuide runs fn in a transaction holding a row lock on the guide, so
+// concurrent item edits apply one at a time, then bumps the guide's version and
+// updated_at. If version is set and the guide has moved past it, nothing runs
+// and a 409 is returned.
+func withLockedGuide(guideID uuid.UUID, version *int, fn func(tx *gorm.DB) error) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var guide models.Guide
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "version").First(&guide, guideID).Error; err != nil {
+			return err
+		}
+		if version != nil && *version != guide.Version {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("This guide has changed since version %d (now %d); reload it and try again", *version, guide.Version))
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return tx.Model(&guide).UpdateColumns(map[string]interface{}{
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		}).Error
+	})
+}
+
+// findGuideItem loads the item named in the route from the given guide
+func findGuideItem(c *fiber.Ctx, guideID uuid.UUID) (*models.GuideItem, error) {
+	itemID, err := uuid.Parse(c.Params("itemId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid item ID")
+	}
+
+	var item models.GuideItem
+	if err := database.DB.Where("guide_id = ?", guideID).First(&item, itemID).Error; err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Guide stop not found")
+	}
+	return &item, nil
+}
+
+// guideItemError passes through client errors raised inside a transaction and
+// turns anything else into a 500 with the given message
+func guideItemError(err error, message string) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, message)
+}
+
+// samePermutation reports whether b lists exactly the IDs in a, each once
+func samePermutation(a, b []uuid.UUID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	remaining := make(map[uuid.UUID]bool, len(a))
+	for _, id := range a {
+		remaining[id] = true
+	}
+	for _, id := range b {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
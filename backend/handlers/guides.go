@@ -0,0 +1,588 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GuideRequest represents guide create and update payload
+type GuideRequest struct {
+	Title       string  `json:"title"`
+	Description *string `json:"description"`
+	City        string  `json:"city"`
+	IsPublic    *bool   `json:"is_public"`
+
+	// Trip dates as YYYY-MM-DD; an empty string clears them
+	StartDate *string `json:"start_date"`
+	EndDate   *string `json:"end_date"`
+
+	// LocationIDs replaces the guide's stops, keeping notes on stops that remain
+	LocationIDs []uuid.UUID `json:"location_ids"`
+}
+
+// GuideResponse is a guide with its stops expanded in the curator's order.
+// Role is the viewer's role on the guide, if any.
+type GuideResponse struct {
+	models.Guide
+	Role  string      `json:"role,omitempty"`
+	Stops []GuideStop `json:"stops"`
+}
+
+// GuideStop is one item in a guide, in the curator's order. Locations in the
+// trash stay in place with Deleted set instead of failing the guide.
+type GuideStop struct {
+	models.GuideItem
+	Deleted bool `json:"deleted"`
+}
+
+// GetGuides returns public guides, plus the caller's own and those they
+// collaborate on, optionally filtered by city and author
+func GetGuides(c *fiber.Ctx) error {
+	query := visibleGuides(database.DB.Preload("User"), optionalUserID(c))
+
+	if cityName := c.Query("city"); cityName != "" {
+		city, err := database.FindCityByName(cityName)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch guides",
+			})
+		}
+		if city != nil {
+			query = query.Where("city_id = ?", city.ID)
+		} else {
+			query = query.Where("city ILIKE ?", "%"+cityName+"%")
+		}
+	}
+
+	if username := c.Query("user"); username != "" {
+		var user models.User
+		if err := database.DB.Where("username = ?", username).First(&user).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		query = query.Where("user_id = ?", user.ID)
+	}
+
+	var guides []models.Guide
+	if err := query.Order("updated_at DESC").Find(&guides).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch guides",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"guides": guides,
+		"count":  len(guides),
+	})
+}
+
+// GetGuide returns a guide with its locations in order (public guides, owner or collaborators)
+func GetGuide(c *fiber.Ctx) error {
+	guideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid guide ID",
+		})
+	}
+
+	viewerID := optionalUserID(c)
+	var guide models.Guide
+	if err := visibleGuides(database.DB.Preload("User").Preload("ForkedFromUser"), viewerID).First(&guide, guideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Guide not found",
+		})
+	}
+
+	stops, err := guideStops(guide.ID, viewerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch guide",
+		})
+	}
+
+	role, err := guideRole(&guide, viewerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch guide",
+		})
+	}
+
+	c.Set(fiber.HeaderETag, guideETag(&guide))
+	return c.JSON(GuideResponse{Guide: guide, Role: role, Stops: stops})
+}
+
+// CreateGuide creates a new guide (requires authentication)
+func CreateGuide(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req GuideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	guide := models.Guide{UserID: userID, IsPublic: true}
+	locationIDs, err := applyGuideRequest(&guide, &req)
+	if err != nil {
+		return err
+	}
+	if guide.Title == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Title is required",
+		})
+	}
+	if guide.City == "" {
+		if err := assignGuideCity(&guide, "", locationIDs); err != nil {
+			return err
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&guide).Error; err != nil {
+			return err
+		}
+		// Create skips false booleans in favour of the column default
+		if !guide.IsPublic {
+			if err := tx.Model(&guide).Update("is_public", false).Error; err != nil {
+				return err
+			}
+		}
+		return replaceGuideItems(tx, guide.ID, locationIDs)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create guide",
+		})
+	}
+
+	return respondGuide(c, fiber.StatusCreated, guide.ID, userID)
+}
+
+// UpdateGuide updates a guide (owner or editors; only the owner can change is_public)
+func UpdateGuide(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findEditableGuide(c, userID)
+	if err != nil {
+		return err
+	}
+	version, err := editedGuideVersion(c, guide)
+	if err != nil {
+		return err
+	}
+
+	var req GuideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.IsPublic != nil && *req.IsPublic != guide.IsPublic && guide.UserID != userID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the owner can change who can see a guide",
+		})
+	}
+	locationIDs, err := applyGuideRequest(guide, &req)
+	if err != nil {
+		return err
+	}
+	if guide.UserID != userID {
+		// Editors can only add places they can open themselves
+		if err := checkGuideLocations(userID, locationIDs...); err != nil {
+			return err
+		}
+	}
+
+	err = withLockedGuide(guide.ID, version, func(tx *gorm.DB) error {
+		if err := tx.Omit("version", "fork_count").Save(guide).Error; err != nil {
+			return err
+		}
+		if locationIDs == nil {
+			return nil
+		}
+		return replaceGuideItems(tx, guide.ID, locationIDs)
+	})
+	if err != nil {
+		return guideItemError(err, "Failed to update guide")
+	}
+
+	return respondGuide(c, fiber.StatusOK, guide.ID, userID)
+}
+
+// DeleteGuide deletes a guide (owner only). An If-Match version is honoured
+// as for updates.
+func DeleteGuide(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findOwnGuide(c, userID)
+	if err != nil {
+		return err
+	}
+
+	version, err := expectedGuideVersion(c)
+	if err != nil {
+		return err
+	}
+
+	// Lock the guide so deleting it waits for item edits in progress
+	err = withLockedGuide(guide.ID, version, func(tx *gorm.DB) error {
+		// Forks keep their author attribution but lose the link
+		if err := tx.Model(&models.Guide{}).Where("forked_from_id = ?", guide.ID).UpdateColumn("forked_from_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(guide).Error; err != nil {
+			return err
+		}
+		if guide.ForkedFromID != nil {
+			return database.RefreshGuideForkCount(tx, *guide.ForkedFromID)
+		}
+		return nil
+	})
+	if err != nil {
+		return guideItemError(err, "Failed to delete guide")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Guide deleted successfully",
+	})
+}
+
+// applyGuideRequest validates the request and copies its fields onto guide.
+// It returns the requested stops, or nil if they are unchanged.
+func applyGuideRequest(guide *models.Guide, req *GuideRequest) ([]uuid.UUID, error) {
+	if title := strings.TrimSpace(req.Title); title != "" {
+		if len([]rune(title)) > models.MaxGuideTitle {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Title is too long")
+		}
+		guide.Title = title
+	}
+	if req.Description != nil {
+		if len([]rune(*req.Description)) > models.MaxGuideDescription {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Description is too long")
+		}
+		guide.Description = req.Description
+	}
+	if req.IsPublic != nil {
+		guide.IsPublic = *req.IsPublic
+	}
+	if err := applyTripDates(guide, req.StartDate, req.EndDate); err != nil {
+		return nil, err
+	}
+
+	var ids []uuid.UUID
+	if req.LocationIDs != nil {
+		ids = make([]uuid.UUID, 0, len(req.LocationIDs))
+		seen := map[uuid.UUID]bool{}
+		for _, id := range req.LocationIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > models.MaxGuideItems {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("A guide can have at most %d stops", models.MaxGuideItems))
+		}
+		if err := checkGuideLocations(guide.UserID, ids...); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.City != "" {
+		if err := assignGuideCity(guide, req.City, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// applyTripDates sets the guide's trip dates, checking that they form a
+// valid range of at most MaxTripDays
+func applyTripDates(guide *models.Guide, start, end *string) error {
+	parse := func(value *string, current *time.Time) (*time.Time, error) {
+		if value == nil {
+			return current, nil
+		}
+		if *value == "" {
+			return nil, nil
+		}
+		date, err := time.Parse("2006-01-02", *value)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Trip dates must be formatted as YYYY-MM-DD")
+		}
+		return &date, nil
+	}
+
+	startDate, err := parse(start, guide.StartDate)
+	if err != nil {
+		return err
+	}
+	endDate, err := parse(end, guide.EndDate)
+	if err != nil {
+		return err
+	}
+	if (startDate == nil) != (endDate == nil) {
+		return fiber.NewError(fiber.StatusBadRequest, "A trip needs both a start and an end date")
+	}
+	guide.StartDate = startDate
+	guide.EndDate = endDate
+
+	if days := guide.TripDays(); startDate != nil && (days < 1 || days > models.MaxTripDays) {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Trips must end on or after their start date and last at most %d days", models.MaxTripDays))
+	}
+	return nil
+}
+
+// checkGuideLocations verifies that the guide's owner can see every location.
+// Guides only ever point at places their owner can open.
+func checkGuideLocations(ownerID uuid.UUID, ids ...uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var count int64
+	err := viewableLocations(database.DB.Model(&models.Location{}), &ownerID).
+		Where("locations.id IN ?", ids).
+		Count(&count).Error
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to save guide")
+	}
+	if count != int64(len(ids)) {
+		return fiber.NewError(fiber.StatusBadRequest, "Some locations don't exist or aren't visible to you")
+	}
+	return nil
+}
+
+// assignGuideCity links a guide to the named city. Without a name it uses the
+// city of the first stop, then the default city.
+func assignGuideCity(guide *models.Guide, name string, locationIDs []uuid.UUID) error {
+	var city *models.City
+	var err error
+	switch {
+	case name != "":
+		city, err = database.FindCityByName(name)
+	case len(locationIDs) > 0:
+		var location models.Location
+		if database.DB.First(&location, locationIDs[0]).Error == nil && location.CityID != nil {
+			city = &models.City{}
+			err = database.DB.First(city, *location.CityID).Error
+		}
+	}
+	if err == nil && city == nil && name == "" {
+		city, err = database.DefaultCity()
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to save guide")
+	}
+
+	if city == nil {
+		guide.CityID = nil
+		guide.City = models.NormalizeCityDisplay(name)
+		return nil
+	}
+	guide.CityID = &city.ID
+	guide.City = city.Name
+	return nil
+}
+
+// findOwnGuide loads the guide named in the route, checking that the caller owns it
+func findOwnGuide(c *fiber.Ctx, userID uuid.UUID) (*models.Guide, error) {
+	return findGuideAs(c, userID, "You can only edit your own guides", models.GuideRoleOwner)
+}
+
+// findEditableGuide loads the guide named in the route, checking that the
+// caller owns it or is one of its editors
+func findEditableGuide(c *fiber.Ctx, userID uuid.UUID) (*models.Guide, error) {
+	return findGuideAs(c, userID, "You don't have permission to edit this guide", models.GuideRoleOwner, models.GuideRoleEditor)
+}
+
+// findGuideAs loads the guide named in the route, checking that the caller
+// has one of the given roles. Guides the caller can't see are not found.
+func findGuideAs(c *fiber.Ctx, userID uuid.UUID, forbidden string, roles ...string) (*models.Guide, error) {
+	guideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid guide ID")
+	}
+
+	var guide models.Guide
+	if err := database.DB.First(&guide, guideID).Error; err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Guide not found")
+	}
+	role, err := guideRole(&guide, &userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch guide")
+	}
+	if err := checkGuideRole(&guide, role, forbidden, roles); err != nil {
+		return nil, err
+	}
+	return &guide, nil
+}
+
+// checkGuideRole allows a caller holding one of roles. Callers with no role
+// get not found for private guides, so their existence isn't revealed.
+func checkGuideRole(guide *models.Guide, role, forbidden string, roles []string) error {
+	for _, allowed := range roles {
+		if role == allowed {
+			return nil
+		}
+	}
+	if role == "" && !guide.IsPublic {
+		return fiber.NewError(fiber.StatusNotFound, "Guide not found")
+	}
+	return fiber.NewError(fiber.StatusForbidden, forbidden)
+}
+
+// guideRole returns the viewer's role on a guide, or "" if they have none
+func guideRole(guide *models.Guide, viewerID *uuid.UUID) (string, error) {
+	if viewerID == nil {
+		return "", nil
+	}
+	if guide.UserID == *viewerID {
+		return models.GuideRoleOwner, nil
+	}
+
+	var collaborator models.GuideCollaborator
+	err := database.DB.Where("guide_id = ? AND user_id = ? AND accepted_at IS NOT NULL", guide.ID, *viewerID).First(&collaborator).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return collaborator.Role, nil
+}
+
+// expectedGuideVersion reads the guide version the client last saw from the
+// If-Match header, or nil if it wasn't sent
+func expectedGuideVersion(c *fiber.Ctx) (*int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "If-Match must be a guide version")
+	}
+	return &version, nil
+}
+
+// editedGuideVersion is the version an edit applies to: the one from If-Match,
+// or without it the one loaded with the guide, so the edit still refuses to
+// overwrite a change committed since
+func editedGuideVersion(c *fiber.Ctx, guide *models.Guide) (*int, error) {
+	version, err := expectedGuideVersion(c)
+	if err != nil || version != nil {
+		return version, err
+	}
+	return &guide.Version, nil
+}
+
+// guideETag is the entity tag for a guide's current version
+func guideETag(guide *models.Guide) string {
+	return strconv.Quote(strconv.Itoa(guide.Version))
+}
+
+// respondGuide reloads a guide and writes it with its stops expanded
+func respondGuide(c *fiber.Ctx, status int, guideID, viewerID uuid.UUID) error {
+	var guide models.Guide
+	if err := database.DB.Preload("User").Preload("ForkedFromUser").First(&guide, guideID).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch guide",
+		})
+	}
+
+	stops, err := guideStops(guide.ID, &viewerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch guide",
+		})
+	}
+	role, err := guideRole(&guide, &viewerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch guide",
+		})
+	}
+
+	c.Set(fiber.HeaderETag, guideETag(&guide))
+	return c.Status(status).JSON(GuideResponse{Guide: guide, Role: role, Stops: stops})
+}
+
+// visibleGuides restricts a guide query to public guides, the viewer's own
+// and those they have accepted an invitation to
+func visibleGuides(query *gorm.DB, viewerID *uuid.UUID) *gorm.DB {
+	if viewerID == nil {
+		return query.Where("guides.is_public = ?", true)
+	}
+	return query.Where(
+		"(guides.is_public = ? OR guides.user_id = ? OR guides.id IN (SELECT guide_id FROM guide_collaborators WHERE user_id = ? AND accepted_at IS NOT NULL))",
+		true, *viewerID, *viewerID,
+	)
+}
+
+// guideStops loads a guide's items in order and expands them for the viewer
+func guideStops(guideID uuid.UUID, viewerID *uuid.UUID) ([]GuideStop, error) {
+	var items []models.GuideItem
+	if err := database.DB.Where("guide_id = ?", guideID).Order("position ASC, created_at ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return expandGuideStops(items, viewerID)
+}
+
+// expandGuideStops attaches locations to guide items, keeping their order.
+// Stops the viewer isn't allowed to see are left out.
+func expandGuideStops(items []models.GuideItem, viewerID *uuid.UUID) ([]GuideStop, error) {
+	stops := make([]GuideStop, 0, len(items))
+	if len(items) == 0 {
+		return stops, nil
+	}
+
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		ids[i] = item.LocationID
+	}
+
+	var locations []models.Location
+	if err := database.DB.Unscoped().Preload("User").Where("id IN ?", ids).Find(&locations).Error; err != nil {
+		return nil, err
+	}
+
+	var viewable []uuid.UUID
+	err := viewableLocations(database.DB.Model(&models.Location{}), viewerID).
+		Where("locations.id IN ?", ids).
+		Pluck("locations.id", &viewable).Error
+	if err != nil {
+		return nil, err
+	}
+	canView := make(map[uuid.UUID]bool, len(viewable))
+	for _, id := range viewable {
+		canView[id] = true
+	}
+
+	byID := make(map[uuid.UUID]*models.Location, len(locations))
+	for i := range locations {
+		byID[locations[i].ID] = &locations[i]
+	}
+
+	for _, item := range items {
+		location, ok := byID[item.LocationID]
+		if !ok || location.DeletedAt.Valid {
+			stops = append(stops, GuideStop{GuideItem: item, Deleted: true})
+			continue
+		}
+		if !canView[item.LocationID] {
+			continue
+		}
+		item.Location = location
+		stops = append(stops, GuideStop{GuideItem: item})
+	}
+	return stops, nil
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestCheckGuideRole(t *testing.T) {
+	editors := []string{models.GuideRoleOwner, models.GuideRoleEditor}
+	ownerOnly := []string{models.GuideRoleOwner}
+
+	tests := []struct {
+		role   string
+		public bool
+		roles  []string
+		status int
+	}{
+		{models.GuideRoleOwner, false, ownerOnly, 0},
+		{models.GuideRoleOwner, false, editors, 0},
+		{models.GuideRoleEditor, false, editors, 0},
+		{models.GuideRoleEditor, false, ownerOnly, fiber.StatusForbidden},
+		{models.GuideRoleViewer, false, editors, fiber.StatusForbidden},
+		{models.GuideRoleViewer, true, editors, fiber.StatusForbidden},
+		// Strangers can see public guides exist but not private ones
+		{"", true, editors, fiber.StatusForbidden},
+		{"", false, editors, fiber.StatusNotFound},
+		{"", false, ownerOnly, fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		guide := &models.Guide{IsPublic: tt.public}
+		err := checkGuideRole(guide, tt.role, "forbidden", tt.roles)
+		status := 0
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		} else if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if status != tt.status {
+			t.Errorf("role %q, public %v, allowed %v: status %d, want %d", tt.role, tt.public, tt.roles, status, tt.status)
+		}
+	}
+}
+
+func TestGuideRoleWithoutCollaborators(t *testing.T) {
+	owner := uuid.New()
+	guide := &models.Guide{ID: uuid.New(), UserID: owner}
+
+	if role, err := guideRole(guide, nil); err != nil || role != "" {
+		t.Errorf("anonymous role = %q, %v", role, err)
+	}
+	if role, err := guideRole(guide, &owner); err != nil || role != models.GuideRoleOwner {
+		t.Errorf("owner role = %q, %v", role, err)
+	}
+}
+
+func TestExpectedGuideVersion(t *testing.T) {
+	app := fiber.New()
+	app.Put("/", func(c *fiber.Ctx) error {
+		version, err := expectedGuideVersion(c)
+		if err != nil {
+			return err
+		}
+		if version == nil {
+			return c.SendString("none")
+		}
+		return c.SendString(strconv.Itoa(*version))
+	})
+
+	tests := []struct {
+		ifMatch string
+		status  int
+		want    string
+	}{
+		{"", fiber.StatusOK, "none"},
+		{"*", fiber.StatusOK, "none"},
+		{`"7"`, fiber.StatusOK, "7"},
+		{`W/"7"`, fiber.StatusOK, "7"},
+		{"12", fiber.StatusOK, "12"},
+		{guideETag(&models.Guide{Version: 42}), fiber.StatusOK, "42"},
+		{`"abc"`, fiber.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("PUT", "/", nil)
+		if tt.ifMatch != "" {
+			req.Header.Set(fiber.HeaderIfMatch, tt.ifMatch)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != tt.status {
+			t.Errorf("If-Match %q: status %d, want %d", tt.ifMatch, resp.StatusCode, tt.status)
+			continue
+		}
+		if tt.status == fiber.StatusOK && string(body) != tt.want {
+			t.Errorf("If-Match %q: version %q, want %q", tt.ifMatch, body, tt.want)
+		}
+	}
+}
+
+func TestEditedGuideVersion(t *testing.T) {
+	guide := &models.Guide{Version: 5}
+	app := fiber.New()
+	app.Put("/", func(c *fiber.Ctx) error {
+		version, err := editedGuideVersion(c, guide)
+		if err != nil {
+			return err
+		}
+		return c.SendString(strconv.Itoa(*version))
+	})
+
+	// Without If-Match the edit applies to the version that was loaded
+	for ifMatch, want := range map[string]string{"": "5", "*": "5", `"3"`: "3"} {
+		req := httptest.NewRequest("PUT", "/", nil)
+		if ifMatch != "" {
+			req.Header.Set(fiber.HeaderIfMatch, ifMatch)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != want {
+			t.Errorf("If-Match %q: version %q, want %q", ifMatch, body, want)
+		}
+	}
+}
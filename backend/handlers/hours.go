@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"myarea-backend/database"
+	"myarea-backend/hours"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// openAtLayouts are the wall-clock formats accepted by open_at, interpreted in
+// each location's own time zone
+var openAtLayouts = []string{"2006-01-02T15:04", "2006-01-02T15:04:05", "2006-01-02 15:04"}
+
+// openFilter is the moment requested by open_now or open_at
+type openFilter struct {
+	instant time.Time
+	// wall is set when the time has no offset and applies in each location's zone
+	wall bool
+}
+
+// at returns the filter's moment in the given time zone
+func (f *openFilter) at(zone *time.Location) time.Time {
+	if !f.wall {
+		return f.instant.In(zone)
+	}
+	t := f.instant
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, zone)
+}
+
+// parseOpenFilter reads open_now=true or open_at=<time>, returning nil if neither is set
+func parseOpenFilter(c *fiber.Ctx) (*openFilter, error) {
+	if openAt := c.Query("open_at"); openAt != "" {
+		if t, err := time.Parse(time.RFC3339, openAt); err == nil {
+			return &openFilter{instant: t}, nil
+		}
+		for _, layout := range openAtLayouts {
+			if t, err := time.Parse(layout, openAt); err == nil {
+				return &openFilter{instant: t, wall: true}, nil
+			}
+		}
+		return nil, fmt.Errorf("open_at must be an RFC 3339 time or a local time like 2006-01-02T15:04")
+	}
+	if isTruthy(c.Query("open_now")) {
+		return &openFilter{instant: time.Now()}, nil
+	}
+	return nil, nil
+}
+
+// filterOpenLocations keeps the locations open at the filter's moment.
+// Locations without opening hours are left out, since they can't be known to be open.
+func filterOpenLocations(locations []models.Location, filter *openFilter) ([]models.Location, error) {
+	zones, err := locationZones(locations)
+	if err != nil {
+		return nil, err
+	}
+
+	open := make([]models.Location, 0, len(locations))
+	for _, location := range locations {
+		schedule := location.Schedule()
+		if schedule == nil {
+			continue
+		}
+		if schedule.IsOpen(filter.at(zoneFor(zones, location.CityID))) {
+			open = append(open, location)
+		}
+	}
+	return open, nil
+}
+
+// locationZones loads the time zones of the locations' cities
+func locationZones(locations []models.Location) (map[uuid.UUID]*time.Location, error) {
+	var cityIDs []uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for _, location := range locations {
+		if location.CityID != nil && !seen[*location.CityID] {
+			seen[*location.CityID] = true
+			cityIDs = append(cityIDs, *location.CityID)
+		}
+	}
+
+	zones := map[uuid.UUID]*time.Location{}
+	if len(cityIDs) == 0 {
+		return zones, nil
+	}
+	var cities []models.City
+	if err := database.DB.Select("id", "time_zone").Where("id IN ?", cityIDs).Find(&cities).Error; err != nil {
+		return nil, err
+	}
+	for i := range cities {
+		zones[cities[i].ID] = cities[i].Zone()
+	}
+	return zones, nil
+}
+
+// zoneFor returns the zone of a city, or the default zone for locations without one
+func zoneFor(zones map[uuid.UUID]*time.Location, cityID *uuid.UUID) *time.Location {
+	if cityID != nil {
+		if zone, ok := zones[*cityID]; ok {
+			return zone
+		}
+	}
+	return models.DefaultZone()
+}
+
+// normalizeOpeningHours validates opening hours given as OSM text or as
+// structured rules and returns the canonical OSM string. An empty string
+// clears the hours.
+func normalizeOpeningHours(text *string, rules *hours.Schedule) (*string, error) {
+	var schedule *hours.Schedule
+	switch {
+	case rules != nil:
+		if err := rules.Validate(); err != nil {
+			return nil, fmt.Errorf("Invalid opening hours: %v", err)
+		}
+		schedule = rules
+	case text != nil && strings.TrimSpace(*text) == "":
+		return nil, nil
+	case text != nil:
+		parsed, err := hours.Parse(*text)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid opening hours: %v", err)
+		}
+		schedule = parsed
+	default:
+		return nil, nil
+	}
+
+	canonical := schedule.String()
+	return &canonical, nil
+}
+
+// openingHoursDetails returns the parsed schedule and whether the location is open now
+func openingHoursDetails(location *models.Location) (*hours.Schedule, *bool, string) {
+	schedule := location.Schedule()
+	if schedule == nil {
+		return nil, nil, ""
+	}
+
+	zones, err := locationZones([]models.Location{*location})
+	if err != nil {
+		return schedule, nil, ""
+	}
+	zone := zoneFor(zones, location.CityID)
+	open := schedule.IsOpen(time.Now().In(zone))
+	return schedule, &open, zone.String()
+}
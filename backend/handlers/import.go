@@ -0,0 +1,475 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"myarea-backend/database"
+	"myarea-backend/geo"
+	"myarea-backend/geofile"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// importInlineRows is the largest import processed within the request;
+	// bigger files run as a background job
+	importInlineRows = 50
+	// importInlineGeocodedRows replaces importInlineRows when a geocoder is
+	// configured, since every row may wait for a rate-limited lookup
+	importInlineGeocodedRows = 5
+	// importMaxRows caps the number of rows in one file
+	importMaxRows = 5000
+	// importMaxRunning caps background imports running at once across all
+	// users; further jobs stay pending until a slot frees up
+	importMaxRunning = 2
+	// importStaleAfter is when an unfinished job no longer counts as running,
+	// e.g. after a restart interrupted it
+	importStaleAfter = 6 * time.Hour
+)
+
+// importSlots limits concurrent background imports, which share the
+// geocoder's rate limit
+var importSlots = make(chan struct{}, importMaxRunning)
+
+// importFieldAliases maps common column and property names onto
+// CreateLocationRequest fields
+var importFieldAliases = map[string]string{
+	"name":          "name",
+	"title":         "name",
+	"place":         "name",
+	"description":   "description",
+	"desc":          "description",
+	"notes":         "description",
+	"note":          "description",
+	"comment":       "description",
+	"category":      "category",
+	"type":          "category",
+	"kind":          "category",
+	"address":       "address",
+	"street":        "address",
+	"city":          "city",
+	"town":          "city",
+	"latitude":      "latitude",
+	"lat":           "latitude",
+	"y":             "latitude",
+	"longitude":     "longitude",
+	"lng":           "longitude",
+	"lon":           "longitude",
+	"long":          "longitude",
+	"x":             "longitude",
+	"tags":          "tags",
+	"opening_hours": "opening_hours",
+	"hours":         "opening_hours",
+	"labels":        "tags",
+	"keywords":      "tags",
+	"rating":        "rating",
+	"stars":         "rating",
+	"price_level":   "price_level",
+	"price":         "price_level",
+	"website_url":   "website_url",
+	"website":       "website_url",
+	"url":           "website_url",
+	"link":          "website_url",
+	"image_url":     "image_url",
+	"image":         "image_url",
+	"photo":         "image_url",
+	"visibility":    "visibility",
+}
+
+// importOptions controls how records become locations
+type importOptions struct {
+	Mapping         map[string]string
+	DefaultCategory string
+	Visibility      string
+	DryRun          bool
+	Force           bool
+}
+
+// ImportLocations creates locations from an uploaded CSV, GeoJSON, KML or GPX file.
+// Small files are processed immediately; larger ones return 202 and a job to poll.
+func ImportLocations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A file is required",
+		})
+	}
+
+	var format geofile.Format
+	if name := c.FormValue("format"); name != "" {
+		format, err = geofile.ParseFormat(name)
+	} else {
+		format, err = geofile.DetectFormat(file.Filename)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Format must be csv, geojson, kml or gpx",
+		})
+	}
+
+	opts := importOptions{
+		DefaultCategory: c.FormValue("default_category", models.CategoryOther),
+		Visibility:      c.FormValue("visibility"),
+		DryRun:          isTruthy(c.FormValue("dry_run")),
+		Force:           isTruthy(c.FormValue("force")),
+	}
+	if mapping := c.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &opts.Mapping); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Mapping must be a JSON object of column to field names",
+			})
+		}
+	}
+	if !models.IsValidCategory(opts.DefaultCategory) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid default category",
+		})
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read file",
+		})
+	}
+	defer f.Close()
+
+	records, err := geofile.Read(format, f)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if len(records) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "The file contains no places",
+		})
+	}
+	if len(records) > importMaxRows {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Files are limited to %d places", importMaxRows),
+		})
+	}
+
+	job := models.ImportJob{
+		UserID:   userID,
+		Format:   string(format),
+		Filename: file.Filename,
+		DryRun:   opts.DryRun,
+		Status:   models.ImportStatusPending,
+		Total:    len(records),
+	}
+	if err := createImportJob(&job); err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return c.Status(fiberErr.Code).JSON(fiber.Map{
+				"error": fiberErr.Message,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start import",
+		})
+	}
+
+	inline := importInlineRows
+	if geo.DefaultGeocoder != nil {
+		inline = importInlineGeocodedRows
+	}
+	if len(records) > inline {
+		go runImportJob(job, records, opts)
+		return c.Status(fiber.StatusAccepted).JSON(job)
+	}
+
+	runImport(c.UserContext(), &job, records, opts)
+	return c.JSON(job)
+}
+
+// createImportJob saves a new job unless the user already has one in progress.
+// The user row is locked so two uploads at once can't both start.
+func createImportJob(job *models.ImportJob) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, job.UserID).Error; err != nil {
+			return err
+		}
+
+		var running int64
+		err := tx.Model(&models.ImportJob{}).
+			Where("user_id = ? AND status IN ? AND created_at > ?", job.UserID,
+				[]string{models.ImportStatusPending, models.ImportStatusRunning}, time.Now().Add(-importStaleAfter)).
+			Count(&running).Error
+		if err != nil {
+			return err
+		}
+		if running > 0 {
+			return fiber.NewError(fiber.StatusTooManyRequests, "Wait for your current import to finish before starting another")
+		}
+		return tx.Create(job).Error
+	})
+}
+
+// GetImportJob returns the status and report of an import (owner only)
+func GetImportJob(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	jobID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid import ID",
+		})
+	}
+
+	var job models.ImportJob
+	if err := database.DB.Where("user_id = ?", userID).First(&job, jobID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Import not found",
+		})
+	}
+
+	return c.JSON(job)
+}
+
+// runImportJob processes an import in the background
+func runImportJob(job models.ImportJob, records []geofile.Record, opts importOptions) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg := fmt.Sprint(r)
+			now := time.Now()
+			job.Status = models.ImportStatusFailed
+			job.Error = &msg
+			job.FinishedAt = &now
+			database.DB.Save(&job)
+			log.Printf("Import %s failed: %v", job.ID, r)
+		}
+	}()
+
+	importSlots <- struct{}{}
+	defer func() { <-importSlots }()
+	runImport(context.Background(), &job, records, opts)
+}
+
+// runImport processes every record and saves the job's progress and report
+func runImport(ctx context.Context, job *models.ImportJob, records []geofile.Record, opts importOptions) {
+	job.Status = models.ImportStatusRunning
+	database.DB.Save(job)
+
+	job.Rows = make(models.ImportReport, 0, len(records))
+	var batch importBatch
+	for i, record := range records {
+		result := importRecord(ctx, job.UserID, record, opts, &batch)
+		job.Rows = append(job.Rows, result)
+		switch result.Status {
+		case models.ImportRowCreated, models.ImportRowValid:
+			// Dry runs count rows that would be created
+			job.Created++
+		case models.ImportRowSkipped:
+			job.Skipped++
+		default:
+			job.Failed++
+		}
+
+		// Report progress on long imports
+		if (i+1)%25 == 0 {
+			database.DB.Model(job).Updates(map[string]interface{}{
+				"created": job.Created,
+				"skipped": job.Skipped,
+				"failed":  job.Failed,
+			})
+		}
+	}
+
+	now := time.Now()
+	job.Status = models.ImportStatusCompleted
+	job.FinishedAt = &now
+	if err := database.DB.Save(job).Error; err != nil {
+		log.Printf("Failed to save import %s: %v", job.ID, err)
+	}
+}
+
+// importBatch remembers the rows of a dry run that would be created, so later
+// rows can be checked against them as they would be against saved locations
+type importBatch struct {
+	valid []importedRow
+}
+
+type importedRow struct {
+	row      int
+	location *models.Location
+}
+
+// duplicateOf returns the earlier row that looks like the same place, or 0
+func (b *importBatch) duplicateOf(location *models.Location) int {
+	for _, earlier := range b.valid {
+		distance := geo.DistanceMeters(location.Latitude, location.Longitude, earlier.location.Latitude, earlier.location.Longitude)
+		if distance <= duplicateRadiusMeters && nameSimilarity(location.Name, earlier.location.Name) >= duplicateNameSimilarity {
+			return earlier.row
+		}
+	}
+	return 0
+}
+
+// importRecord validates one record and, unless this is a dry run, creates it
+func importRecord(ctx context.Context, userID uuid.UUID, record geofile.Record, opts importOptions, batch *importBatch) models.ImportRowResult {
+	result := models.ImportRowResult{Row: record.Row}
+
+	req, warnings, err := recordToRequest(record, opts)
+	result.Name = req.Name
+	result.Warnings = warnings
+	if err != nil {
+		result.Status = models.ImportRowFailed
+		result.Error = err.Error()
+		return result
+	}
+	if req.Name == "" && req.Address == "" && req.Latitude == nil {
+		result.Status = models.ImportRowSkipped
+		result.Error = "Empty row"
+		return result
+	}
+
+	location, locWarnings, err := prepareLocation(ctx, userID, &req)
+	for _, w := range locWarnings {
+		result.Warnings = append(result.Warnings, w.Message)
+	}
+	if err != nil {
+		var locErr *LocationError
+		switch {
+		case errors.As(err, &locErr) && locErr.Status == fiber.StatusConflict:
+			result.Status = models.ImportRowSkipped
+			result.Error = "Possible duplicate of an existing location"
+			if len(locErr.Duplicates) > 0 {
+				id := locErr.Duplicates[0].Location.ID
+				result.LocationID = &id
+			}
+		case errors.As(err, &locErr):
+			result.Status = models.ImportRowFailed
+			result.Error = locErr.Message
+		default:
+			result.Status = models.ImportRowFailed
+			result.Error = "Failed to create location"
+		}
+		return result
+	}
+
+	if opts.DryRun {
+		// Nothing is saved, so look for the same place earlier in the file
+		if row := batch.duplicateOf(location); row > 0 && !opts.Force {
+			result.Status = models.ImportRowSkipped
+			result.Error = fmt.Sprintf("Possible duplicate of row %d", row)
+			return result
+		}
+		batch.valid = append(batch.valid, importedRow{row: record.Row, location: location})
+		result.Status = models.ImportRowValid
+		result.Preview = location
+		return result
+	}
+
+	if err := saveNewLocation(location, userID); err != nil {
+		result.Status = models.ImportRowFailed
+		result.Error = "Failed to create location"
+		return result
+	}
+	result.Status = models.ImportRowCreated
+	result.LocationID = &location.ID
+	return result
+}
+
+// recordToRequest maps a record's properties onto a creation request
+func recordToRequest(record geofile.Record, opts importOptions) (CreateLocationRequest, []string, error) {
+	req := CreateLocationRequest{
+		Latitude:   record.Latitude,
+		Longitude:  record.Longitude,
+		Visibility: opts.Visibility,
+		Force:      opts.Force,
+	}
+	var warnings []string
+
+	for key, value := range record.Properties {
+		if value == "" {
+			continue
+		}
+		field, ok := opts.Mapping[key]
+		if !ok {
+			field = importFieldAliases[strings.ToLower(strings.TrimSpace(key))]
+		}
+
+		switch field {
+		case "name":
+			req.Name = value
+		case "description":
+			v := value
+			req.Description = &v
+		case "category":
+			req.Category = models.NormalizeTag(value)
+		case "address":
+			req.Address = value
+		case "city":
+			req.City = value
+		case "latitude", "longitude":
+			if (field == "latitude" && record.Latitude != nil) || (field == "longitude" && record.Longitude != nil) {
+				continue // geometry wins over properties
+			}
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return req, warnings, fmt.Errorf("Invalid %s %q", field, value)
+			}
+			if field == "latitude" {
+				req.Latitude = &f
+			} else {
+				req.Longitude = &f
+			}
+		case "tags":
+			req.Tags = strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' || r == '|' })
+		case "rating", "price_level":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return req, warnings, fmt.Errorf("Invalid %s %q", field, value)
+			}
+			if field == "rating" {
+				req.Rating = &n
+			} else {
+				req.PriceLevel = &n
+			}
+		case "website_url":
+			v := value
+			req.WebsiteURL = &v
+		case "image_url":
+			v := value
+			req.ImageURL = &v
+		case "visibility":
+			req.Visibility = strings.ToLower(value)
+		case "opening_hours":
+			v := value
+			req.OpeningHours = &v
+		}
+	}
+
+	if req.Category == "" || !models.IsValidCategory(req.Category) {
+		if req.Category != "" {
+			warnings = append(warnings, fmt.Sprintf("Unknown category %q, using %q", req.Category, opts.DefaultCategory))
+		}
+		req.Category = opts.DefaultCategory
+	}
+
+	return req, warnings, nil
+}
+
+// isTruthy parses form booleans such as "true", "1" and "yes"
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"testing"
+
+	"myarea-backend/geofile"
+	"myarea-backend/models"
+)
+
+func TestRecordToRequest(t *testing.T) {
+	record := geofile.Record{
+		Row: 2,
+		Properties: map[string]string{
+			"Title":   "Tartine",
+			"type":    "Cafe",
+			"lat":     "37.7614",
+			"lng":     "-122.4241",
+			"labels":  "bakery; coffee|brunch",
+			"stars":   "5",
+			"Website": "https://tartinebakery.com",
+			"Hours":   "Mo-Su 07:30-17:00",
+			"ignored": "value",
+		},
+	}
+	req, warnings, err := recordToRequest(record, importOptions{DefaultCategory: models.CategoryOther})
+	if err != nil {
+		t.Fatalf("recordToRequest: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+	if req.Name != "Tartine" || req.Category != models.CategoryCafe {
+		t.Errorf("name/category = %q/%q", req.Name, req.Category)
+	}
+	if req.Latitude == nil || *req.Latitude != 37.7614 || req.Longitude == nil || *req.Longitude != -122.4241 {
+		t.Errorf("coordinates = %v, %v", req.Latitude, req.Longitude)
+	}
+	if len(req.Tags) != 3 || req.Rating == nil || *req.Rating != 5 {
+		t.Errorf("tags/rating = %v/%v", req.Tags, req.Rating)
+	}
+	if req.WebsiteURL == nil || req.OpeningHours == nil {
+		t.Error("website and opening hours should be mapped")
+	}
+}
+
+func TestRecordToRequestGeometryWins(t *testing.T) {
+	lat, lng := 1.0, 2.0
+	record := geofile.Record{
+		Latitude:   &lat,
+		Longitude:  &lng,
+		Properties: map[string]string{"name": "Pin", "lat": "50", "lon": "60", "category": "volcano"},
+	}
+	req, warnings, err := recordToRequest(record, importOptions{DefaultCategory: models.CategoryOther})
+	if err != nil {
+		t.Fatalf("recordToRequest: %v", err)
+	}
+	if *req.Latitude != 1 || *req.Longitude != 2 {
+		t.Errorf("coordinates = %v, %v, want the geometry", *req.Latitude, *req.Longitude)
+	}
+	if req.Category != models.CategoryOther || len(warnings) != 1 {
+		t.Errorf("unknown category: got %q with warnings %v", req.Category, warnings)
+	}
+}
+
+func TestRecordToRequestMapping(t *testing.T) {
+	record := geofile.Record{Properties: map[string]string{"Spot": "Dolores Park", "rating": "great"}}
+
+	opts := importOptions{DefaultCategory: models.CategoryPark, Mapping: map[string]string{"Spot": "name"}}
+	if _, _, err := recordToRequest(record, opts); err == nil {
+		t.Error("expected an invalid rating error")
+	}
+
+	delete(record.Properties, "rating")
+	req, _, err := recordToRequest(record, opts)
+	if err != nil {
+		t.Fatalf("recordToRequest: %v", err)
+	}
+	if req.Name != "Dolores Park" || req.Category != models.CategoryPark {
+		t.Errorf("name/category = %q/%q", req.Name, req.Category)
+	}
+}
+
+func TestImportBatchDuplicates(t *testing.T) {
+	var batch importBatch
+	batch.valid = append(batch.valid,
+		importedRow{row: 2, location: &models.Location{Name: "Tartine Bakery", Latitude: 37.7614, Longitude: -122.4241}},
+		importedRow{row: 3, location: &models.Location{Name: "Dolores Park", Latitude: 37.7596, Longitude: -122.4269}},
+	)
+
+	tests := []struct {
+		location models.Location
+		want     int
+	}{
+		// Same name a few meters away
+		{models.Location{Name: "Tartine", Latitude: 37.7615, Longitude: -122.4240}, 2},
+		{models.Location{Name: "Mission Dolores Park", Latitude: 37.7597, Longitude: -122.4268}, 3},
+		// Different place at the same spot
+		{models.Location{Name: "Bi-Rite Creamery", Latitude: 37.7614, Longitude: -122.4241}, 0},
+		// Same name across town
+		{models.Location{Name: "Tartine Bakery", Latitude: 37.7836, Longitude: -122.4089}, 0},
+	}
+	for _, tt := range tests {
+		if got := batch.duplicateOf(&tt.location); got != tt.want {
+			t.Errorf("duplicateOf(%q) = %d, want %d", tt.location.Name, got, tt.want)
+		}
+	}
+}
+
+func TestIsTruthy(t *testing.T) {
+	for _, v := range []string{"1", "true", "Yes", " on "} {
+		if !isTruthy(v) {
+			t.Errorf("isTruthy(%q) = false", v)
+		}
+	}
+	for _, v := range []string{"", "0", "false", "no", "maybe"} {
+		if isTruthy(v) {
+			t.Errorf("isTruthy(%q) = true", v)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+	"myarea-backend/routing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// defaultVisitMinutes is assumed for stops with a start time but no duration
+const defaultVisitMinutes = 60
+
+// slotWindows are the [start, end) minutes of each time slot. Night runs past midnight.
+var slotWindows = map[string][2]int{
+	models.SlotMorning:   {8 * 60, 12 * 60},
+	models.SlotAfternoon: {12 * 60, 17 * 60},
+	models.SlotEvening:   {17 * 60, 21 * 60},
+	models.SlotNight:     {21 * 60, 26 * 60},
+}
+
+// ItineraryResponse is a guide's stops grouped by day
+type ItineraryResponse struct {
+	Guide models.Guide `json:"guide"`
+	Mode  routing.Mode `json:"mode"`
+	// CalendarURL is a subscribable iCalendar feed, set when the guide is
+	// public or opened through a share link
+	CalendarURL string          `json:"calendar_url,omitempty"`
+	Days        []ItineraryDay  `json:"days"`
+	Unscheduled []ItineraryStop `json:"unscheduled"`
+}
+
+// ItineraryDay is one day of a trip. Date is set when the guide has trip dates.
+type ItineraryDay struct {
+	Day     int             `json:"day"`
+	Date    string          `json:"date,omitempty"`
+	Weekday string          `json:"weekday,omitempty"`
+	Stops   []ItineraryStop `json:"stops"`
+
+	TravelDistanceMeters  float64 `json:"travel_distance_meters"`
+	TravelDurationSeconds float64 `json:"travel_duration_seconds"`
+}
+
+// ItineraryStop is a guide stop with any problems found with its timing. Leg
+// is the travel from the previous stop of the day.
+type ItineraryStop struct {
+	GuideStop
+	Leg      *routing.Leg      `json:"leg,omitempty"`
+	Warnings []LocationWarning `json:"warnings,omitempty"`
+}
+
+// GetGuideItinerary returns a guide's plan grouped by day, with stops in time
+// order, travel between them (?mode=walk|bike|drive) and warnings where the
+// plan conflicts with opening hours
+func GetGuideItinerary(c *fiber.Ctx) error {
+	guideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid guide ID",
+		})
+	}
+
+	viewerID := optionalUserID(c)
+	var guide models.Guide
+	if err := visibleGuides(database.DB.Preload("User"), viewerID).First(&guide, guideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Guide not found",
+		})
+	}
+
+	return respondItinerary(c, &guide, viewerID, calendarFeedURL(c, &guide, ""))
+}
+
+// GetSharedGuideItinerary returns the itinerary of a guide opened through a share link
+func GetSharedGuideItinerary(c *fiber.Ctx) error {
+	guide, share, err := findSharedGuide(c)
+	if err != nil {
+		return err
+	}
+	return respondItinerary(c, guide, optionalUserID(c), calendarFeedURL(c, guide, share.Token))
+}
+
+// respondItinerary builds a guide's itinerary for the viewer and writes it
+func respondItinerary(c *fiber.Ctx, guide *models.Guide, viewerID *uuid.UUID, feedURL string) error {
+	mode, err := routing.ParseMode(c.Query("mode"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Mode must be walk, bike or drive",
+		})
+	}
+
+	stops, err := guideStops(guide.ID, viewerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch itinerary",
+		})
+	}
+
+	itinerary, err := buildItinerary(guide, stops)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch itinerary",
+		})
+	}
+	attachTravelLegs(c.UserContext(), itinerary, mode)
+	itinerary.CalendarURL = feedURL
+	return c.JSON(itinerary)
+}
+
+const (
+	// legConcurrency caps the routing requests in flight for one itinerary
+	legConcurrency = 8
+	// legTimeout bounds the time spent routing an itinerary; legs that don't
+	// finish in time fall back to straight-line estimates
+	legTimeout = 5 * time.Second
+)
+
+// attachTravelLegs fills in the travel between consecutive stops of each day
+// and the day's totals. Stops without a location break the chain. Legs are
+// routed concurrently under legTimeout.
+func attachTravelLegs(ctx context.Context, itinerary *ItineraryResponse, mode routing.Mode) {
+	itinerary.Mode = mode
+
+	type legRequest struct {
+		stop     *ItineraryStop
+		from, to routing.Point
+	}
+	var requests []legRequest
+	for d := range itinerary.Days {
+		day := &itinerary.Days[d]
+		var previous *models.Location
+		for i := range day.Stops {
+			location := day.Stops[i].Location
+			if location == nil {
+				previous = nil
+				continue
+			}
+			if previous != nil {
+				requests = append(requests, legRequest{
+					stop: &day.Stops[i],
+					from: routing.Point{Latitude: previous.Latitude, Longitude: previous.Longitude},
+					to:   routing.Point{Latitude: location.Latitude, Longitude: location.Longitude},
+				})
+			}
+			previous = location
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, legTimeout)
+	defer cancel()
+
+	// Each request writes only its own stop, so no locking is needed
+	queue := make(chan legRequest)
+	var wg sync.WaitGroup
+	for w := 0; w < min(legConcurrency, len(requests)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range queue {
+				if leg, err := routing.DefaultRouter.Route(ctx, mode, req.from, req.to); err == nil {
+					leg.DistanceMeters = math.Round(leg.DistanceMeters)
+					leg.DurationSeconds = math.Round(leg.DurationSeconds)
+					req.stop.Leg = leg
+				}
+			}
+		}()
+	}
+	for _, req := range requests {
+		queue <- req
+	}
+	close(queue)
+	wg.Wait()
+
+	for d := range itinerary.Days {
+		day := &itinerary.Days[d]
+		for _, stop := range day.Stops {
+			if stop.Leg != nil {
+				day.TravelDistanceMeters += stop.Leg.DistanceMeters
+				day.TravelDurationSeconds += stop.Leg.DurationSeconds
+			}
+		}
+	}
+}
+
+// buildItinerary groups stops by day and checks them against opening hours
+func buildItinerary(guide *models.Guide, stops []GuideStop) (*ItineraryResponse, error) {
+	locations := make([]models.Location, 0, len(stops))
+	for _, stop := range stops {
+		if stop.Location != nil {
+			locations = append(locations, *stop.Location)
+		}
+	}
+	zones, err := locationZones(locations)
+	if err != nil {
+		return nil, err
+	}
+
+	itinerary := &ItineraryResponse{Guide: *guide, Days: []ItineraryDay{}, Unscheduled: []ItineraryStop{}}
+	byDay := map[int][]ItineraryStop{}
+	tripDays := guide.TripDays()
+	for day := 1; day <= tripDays; day++ {
+		byDay[day] = []ItineraryStop{}
+	}
+
+	for _, stop := range stops {
+		if stop.Day == nil {
+			itinerary.Unscheduled = append(itinerary.Unscheduled, ItineraryStop{GuideStop: stop})
+			continue
+		}
+		day := *stop.Day
+		entry := ItineraryStop{GuideStop: stop}
+		if tripDays > 0 && day > tripDays {
+			entry.Warnings = append(entry.Warnings, LocationWarning{
+				Code:    "outside_trip",
+				Message: fmt.Sprintf("Day %d is after the end of the trip", day),
+			})
+		} else if date := tripDate(guide, day); date != nil && stop.Location != nil {
+			if warning := openingHoursWarning(stop.Location, &stop.GuideItem, *date, zoneFor(zones, stop.Location.CityID)); warning != nil {
+				entry.Warnings = append(entry.Warnings, *warning)
+			}
+		}
+		byDay[day] = append(byDay[day], entry)
+	}
+
+	days := make([]int, 0, len(byDay))
+	for day := range byDay {
+		days = append(days, day)
+	}
+	sort.Ints(days)
+
+	for _, day := range days {
+		entry := ItineraryDay{Day: day, Stops: sortByTimeOfDay(byDay[day])}
+		if date := tripDate(guide, day); date != nil {
+			entry.Date = date.Format("2006-01-02")
+			entry.Weekday = date.Weekday().String()
+		}
+		itinerary.Days = append(itinerary.Days, entry)
+	}
+	return itinerary, nil
+}
+
+// tripDate returns the calendar date of a trip day, or nil without trip dates
+func tripDate(guide *models.Guide, day int) *time.Time {
+	if guide.StartDate == nil {
+		return nil
+	}
+	date := guide.StartDate.AddDate(0, 0, day-1)
+	return &date
+}
+
+// openingHoursWarning checks a planned visit against the location's opening
+// hours. It returns nil when the hours are unknown or the visit fits.
+func openingHoursWarning(location *models.Location, item *models.GuideItem, date time.Time, zone *time.Location) *LocationWarning {
+	schedule := location.Schedule()
+	if schedule == nil {
+		return nil
+	}
+
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, zone)
+	at := func(minute int) time.Time { return midnight.Add(time.Duration(minute) * time.Minute) }
+
+	switch {
+	case item.StartTime != nil:
+		start := clockMinutes(*item.StartTime)
+		duration := defaultVisitMinutes
+		if item.DurationMinutes != nil {
+			duration = *item.DurationMinutes
+		}
+		if !schedule.OpenThroughout(at(start), at(start+duration)) {
+			return &LocationWarning{
+				Code:       "closed",
+				Message:    fmt.Sprintf("%s may be closed during the planned visit at %s", location.Name, *item.StartTime),
+				LocationID: &location.ID,
+			}
+		}
+	case item.Slot != nil:
+		window := slotWindows[*item.Slot]
+		if !schedule.OpenDuring(at(window[0]), at(window[1])) {
+			return &LocationWarning{
+				Code:       "closed",
+				Message:    fmt.Sprintf("%s is closed in the %s", location.Name, *item.Slot),
+				LocationID: &location.ID,
+			}
+		}
+	default:
+		if !schedule.OpenDuring(at(0), at(24*60)) {
+			return &LocationWarning{
+				Code:       "closed",
+				Message:    fmt.Sprintf("%s is closed on %s", location.Name, date.Format("Monday, Jan 2")),
+				LocationID: &location.ID,
+			}
+		}
+	}
+	return nil
+}
+
+// sortByTimeOfDay orders a day's stops by start time or slot. Stops without
+// either follow the stop before them in the curator's order.
+func sortByTimeOfDay(stops []ItineraryStop) []ItineraryStop {
+	keys := make(map[uuid.UUID]int, len(stops))
+	last := 0
+	for _, stop := range stops {
+		switch {
+		case stop.StartTime != nil:
+			last = clockMinutes(*stop.StartTime)
+		case stop.Slot != nil:
+			last = slotWindows[*stop.Slot][0]
+		}
+		keys[stop.ID] = last
+	}
+	sort.SliceStable(stops, func(i, j int) bool {
+		return keys[stops[i].ID] < keys[stops[j].ID]
+	})
+	return stops
+}
+
+// clockMinutes converts a validated HH:MM time to minutes since midnight
+func clockMinutes(clock string) int {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0
+	}
+	return t.Hour()*60 + t.Minute()
+}
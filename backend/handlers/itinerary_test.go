@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"myarea-backend/models"
+	"myarea-backend/routing"
+)
+
+// slowRouter takes a fixed time per leg and records the peak number of
+// legs routed at once
+type slowRouter struct {
+	delay time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	calls    int
+}
+
+func (r *slowRouter) Route(ctx context.Context, mode routing.Mode, from, to routing.Point) (*routing.Leg, error) {
+	r.mu.Lock()
+	r.calls++
+	r.inFlight++
+	r.peak = max(r.peak, r.inFlight)
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.inFlight--
+		r.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(r.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &routing.Leg{Mode: mode, DistanceMeters: 100.4, DurationSeconds: 60.6}, nil
+}
+
+func itineraryStop(location *models.Location) ItineraryStop {
+	var stop ItineraryStop
+	stop.Location = location
+	return stop
+}
+
+func TestAttachTravelLegs(t *testing.T) {
+	router := &slowRouter{delay: 50 * time.Millisecond}
+	previous := routing.DefaultRouter
+	routing.DefaultRouter = router
+	defer func() { routing.DefaultRouter = previous }()
+
+	at := func(lat float64) *models.Location {
+		return &models.Location{Latitude: lat, Longitude: -122.4}
+	}
+	itinerary := &ItineraryResponse{Days: []ItineraryDay{
+		{Day: 1, Stops: []ItineraryStop{itineraryStop(at(37.70)), itineraryStop(at(37.71)), itineraryStop(at(37.72)), itineraryStop(at(37.73))}},
+		// A stop whose location was deleted breaks the chain
+		{Day: 2, Stops: []ItineraryStop{itineraryStop(at(37.80)), itineraryStop(nil), itineraryStop(at(37.81)), itineraryStop(at(37.82))}},
+		{Day: 3, Stops: []ItineraryStop{itineraryStop(at(37.90))}},
+	}}
+
+	start := time.Now()
+	attachTravelLegs(context.Background(), itinerary, routing.ModeWalk)
+	elapsed := time.Since(start)
+
+	if router.calls != 4 {
+		t.Errorf("routed %d legs, want 4", router.calls)
+	}
+	if router.peak < 2 {
+		t.Errorf("legs were routed one at a time")
+	}
+	if elapsed >= 4*router.delay {
+		t.Errorf("took %s, no faster than routing serially", elapsed)
+	}
+
+	day1, day2, day3 := itinerary.Days[0], itinerary.Days[1], itinerary.Days[2]
+	if day1.Stops[0].Leg != nil {
+		t.Error("first stop of the day has a leg")
+	}
+	for _, stop := range day1.Stops[1:] {
+		if stop.Leg == nil || stop.Leg.DistanceMeters != 100 || stop.Leg.DurationSeconds != 61 {
+			t.Errorf("leg = %+v, want rounded 100 m / 61 s", stop.Leg)
+		}
+	}
+	if day1.TravelDistanceMeters != 300 || day1.TravelDurationSeconds != 183 {
+		t.Errorf("day 1 totals = %v m, %v s", day1.TravelDistanceMeters, day1.TravelDurationSeconds)
+	}
+	if day2.Stops[1].Leg != nil || day2.Stops[2].Leg != nil || day2.Stops[3].Leg == nil {
+		t.Error("deleted location should break the chain of legs")
+	}
+	if day2.TravelDistanceMeters != 100 {
+		t.Errorf("day 2 distance = %v", day2.TravelDistanceMeters)
+	}
+	if day3.TravelDistanceMeters != 0 || itinerary.Mode != routing.ModeWalk {
+		t.Errorf("day 3 = %+v, mode %q", day3, itinerary.Mode)
+	}
+}
+
+func TestAttachTravelLegsDeadline(t *testing.T) {
+	router := &slowRouter{delay: time.Hour}
+	previous := routing.DefaultRouter
+	routing.DefaultRouter = router
+	defer func() { routing.DefaultRouter = previous }()
+
+	itinerary := &ItineraryResponse{Days: []ItineraryDay{{Day: 1, Stops: []ItineraryStop{
+		itineraryStop(&models.Location{Latitude: 37.7}),
+		itineraryStop(&models.Location{Latitude: 37.8}),
+	}}}}
+
+	// A cancelled request stops waiting for the engine
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	attachTravelLegs(ctx, itinerary, routing.ModeDrive)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %s after the deadline", elapsed)
+	}
+	if itinerary.Days[0].Stops[1].Leg != nil {
+		t.Error("leg attached for a route that timed out")
+	}
+}
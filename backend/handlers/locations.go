@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"context"
+	"strings"
+
 	"myarea-backend/database"
+	"myarea-backend/geo"
+	"myarea-backend/hours"
 	"myarea-backend/models"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"gorm.io/gorm"
 )
 
 // CreateLocationRequest represents location creation payload
@@ -14,23 +20,77 @@ type CreateLocationRequest struct {
 	Name        string   `json:"name" validate:"required"`
 	Description *string  `json:"description"`
 	Category    string   `json:"category" validate:"required"`
-	Address     string   `json:"address" validate:"required"`
-	Latitude    float64  `json:"latitude" validate:"required"`
-	Longitude   float64  `json:"longitude" validate:"required"`
-	City        string   `json:"city" validate:"required"`
+	Address     string   `json:"address"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+	City        string   `json:"city"`
 	Rating      *int     `json:"rating"`
 	PriceLevel  *int     `json:"price_level"`
 	Tags        pq.StringArray `json:"tags"`
 	ImageURL    *string  `json:"image_url"`
 	WebsiteURL  *string  `json:"website_url"`
+	Visibility  string   `json:"visibility"`
+
+	// Opening hours in OSM syntax, or as structured rules; an empty string clears them
+	OpeningHours      *string         `json:"opening_hours"`
+	OpeningHoursRules *hours.Schedule `json:"opening_hours_rules"`
+
+	// Force creates the location even if likely duplicates exist
+	Force bool `json:"force"`
+}
+
+// LocationWarning is a non-fatal issue found while saving a location
+type LocationWarning struct {
+	Code       string     `json:"code"`
+	Message    string     `json:"message"`
+	LocationID *uuid.UUID `json:"location_id,omitempty"`
+}
+
+// LocationResponse is a location plus extra details for the client
+type LocationResponse struct {
+	models.Location
+	Warnings      []LocationWarning `json:"warnings,omitempty"`
+	RevisionCount *int64            `json:"revision_count,omitempty"`
+	Hours         *hours.Schedule   `json:"hours,omitempty"`
+	OpenNow       *bool             `json:"open_now,omitempty"`
+	TimeZone      string            `json:"time_zone,omitempty"`
+	IsSaved       *bool             `json:"is_saved,omitempty"`
 }
 
-// GetLocations returns all public locations, optionally filtered by city and category
+// GetLocations returns all public locations, optionally filtered by city,
+// category, tag and whether they are open now or at a given time
 func GetLocations(c *fiber.Ctx) error {
-	city := c.Query("city", "San Francisco") // Default to San Francisco for Phase 1
+	cityName := c.Query("city")
 	category := c.Query("category")
 
-	query := database.DB.Preload("User").Where("city ILIKE ?", "%"+city+"%")
+	format, err := exportFormat(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Format must be json, geojson, kml, gpx or csv",
+		})
+	}
+
+	openAt, err := parseOpenFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	city, err := resolveCityFilter(cityName)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch locations",
+		})
+	}
+
+	query := visibleLocations(preloadCoverPhoto(database.DB.Preload("User")), optionalUserID(c))
+	if city != nil {
+		query = query.Where("city_id = ?", city.ID)
+	} else if cityName != "" {
+		// Not a known city; fall back to matching the free-text city
+		query = query.Where("city ILIKE ?", "%"+cityName+"%")
+	}
 
 	if category != "" {
 		if !models.IsValidCategory(category) {
@@ -38,7 +98,28 @@ func GetLocations(c *fiber.Ctx) error {
 				"error": "Invalid category",
 			})
 		}
-		query = query.Where("category = ?", category)
+		// Include subcategories, so filtering by a parent matches its children
+		slugs, err := database.CategoryDescendantSlugs(category)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch locations",
+			})
+		}
+		query = query.Where("category IN ?", slugs)
+	}
+
+	if tag := c.Query("tag"); tag != "" {
+		tags, err := database.CanonicalTags([]string{tag})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch locations",
+			})
+		}
+		query = query.Where("tags @> ?", tags)
+	}
+
+	if openAt != nil {
+		query = query.Where("opening_hours IS NOT NULL")
 	}
 
 	var locations []models.Location
@@ -48,9 +129,35 @@ func GetLocations(c *fiber.Ctx) error {
 		})
 	}
 
+	// Opening hours depend on each location's time zone, so they are checked here
+	if openAt != nil {
+		locations, err = filterOpenLocations(locations, openAt)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch locations",
+			})
+		}
+	}
+
+	if format != "" {
+		title := "MyArea locations"
+		if city != nil {
+			title = "MyArea " + city.Name
+		}
+		return sendLocationsExport(c, format, title, locations)
+	}
+
+	responses, err := withSavedFlags(locations, optionalUserID(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch locations",
+		})
+	}
+
 	return c.JSON(fiber.Map{
-		"locations": locations,
-		"count":     len(locations),
+		"locations": responses,
+		"count":     len(responses),
+		"city":      city,
 	})
 }
 
@@ -64,13 +171,48 @@ func GetLocation(c *fiber.Ctx) error {
 	}
 
 	var location models.Location
-	if err := database.DB.Preload("User").First(&location, locationID).Error; err != nil {
+	query := database.DB.Preload("User").
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Photos.Image").
+		Preload("Photos.Uploader")
+	query = viewableLocations(query, optionalUserID(c))
+	if err := query.First(&location, locationID).Error; err != nil {
+		// Locations merged into another one redirect to it
+		var alias models.LocationAlias
+		if database.DB.First(&alias, "old_id = ?", locationID).Error == nil {
+			target := strings.TrimSuffix(c.Path(), c.Params("id")) + alias.LocationID.String()
+			return c.Redirect(target, fiber.StatusMovedPermanently)
+		}
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Location not found",
 		})
 	}
 
-	return c.JSON(location)
+	revisionCount, err := database.CountLocationRevisions(location.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch location",
+		})
+	}
+
+	schedule, openNow, timeZone := openingHoursDetails(&location)
+
+	response := LocationResponse{
+		Location:      location,
+		RevisionCount: &revisionCount,
+		Hours:         schedule,
+		OpenNow:       openNow,
+		TimeZone:      timeZone,
+	}
+	if viewerID := optionalUserID(c); viewerID != nil {
+		saved, err := database.SavedLocationIDs(*viewerID, []uuid.UUID{location.ID})
+		if err == nil {
+			isSaved := saved[location.ID]
+			response.IsSaved = &isSaved
+		}
+	}
+
+	return c.JSON(response)
 }
 
 // CreateLocation creates a new location (requires authentication)
@@ -84,61 +226,156 @@ func CreateLocation(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate required fields
-	if req.Name == "" || req.Category == "" || req.Address == "" || req.City == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Name, category, address, and city are required",
+	location, warnings, err := prepareLocation(c.UserContext(), userID, &req)
+	if err != nil {
+		return respondLocationError(c, err)
+	}
+
+	if err := saveNewLocation(location, userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create location",
 		})
 	}
 
+	// Load user info for response
+	database.DB.Preload("User").First(location, location.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(LocationResponse{
+		Location: *location,
+		Warnings: warnings,
+	})
+}
+
+// LocationError is a validation failure from prepareLocation
+type LocationError struct {
+	Status     int
+	Message    string
+	Duplicates []DuplicateCandidate
+	Warnings   []LocationWarning
+}
+
+func (e *LocationError) Error() string {
+	return e.Message
+}
+
+// respondLocationError writes a prepareLocation error as a JSON response
+func respondLocationError(c *fiber.Ctx, err error) error {
+	locErr, ok := err.(*LocationError)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create location",
+		})
+	}
+
+	body := fiber.Map{"error": locErr.Message}
+	if len(locErr.Duplicates) > 0 {
+		body["duplicates"] = locErr.Duplicates
+	}
+	if len(locErr.Warnings) > 0 {
+		body["warnings"] = locErr.Warnings
+	}
+	return c.Status(locErr.Status).JSON(body)
+}
+
+// prepareLocation validates a creation request, fills in missing geography
+// and checks for duplicates. It returns the unsaved location, or a
+// *LocationError describing why it can't be created.
+func prepareLocation(ctx context.Context, userID uuid.UUID, req *CreateLocationRequest) (*models.Location, []LocationWarning, error) {
+	req.Name = strings.TrimSpace(req.Name)
+
+	// Validate required fields
+	if req.Name == "" || req.Category == "" {
+		return nil, nil, &LocationError{Status: fiber.StatusBadRequest, Message: "Name and category are required"}
+	}
+
 	// Validate category
 	if !models.IsValidCategory(req.Category) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid category",
-		})
+		return nil, nil, &LocationError{Status: fiber.StatusBadRequest, Message: "Invalid category"}
 	}
 
 	// Validate rating if provided
 	if req.Rating != nil && (*req.Rating < 1 || *req.Rating > 5) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Rating must be between 1 and 5",
-		})
+		return nil, nil, &LocationError{Status: fiber.StatusBadRequest, Message: "Rating must be between 1 and 5"}
 	}
 
 	// Validate price level if provided
 	if req.PriceLevel != nil && (*req.PriceLevel < 1 || *req.PriceLevel > 4) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Price level must be between 1 and 4",
-		})
+		return nil, nil, &LocationError{Status: fiber.StatusBadRequest, Message: "Price level must be between 1 and 4"}
+	}
+
+	// Validate visibility if provided
+	if req.Visibility == "" {
+		req.Visibility = models.VisibilityPublic
+	} else if !models.IsValidVisibility(req.Visibility) {
+		return nil, nil, &LocationError{Status: fiber.StatusBadRequest, Message: "Visibility must be public, unlisted, friends or private"}
+	}
+
+	// Normalize tags and apply synonyms
+	tags, err := database.CanonicalTags(req.Tags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	openingHours, err := normalizeOpeningHours(req.OpeningHours, req.OpeningHoursRules)
+	if err != nil {
+		return nil, nil, &LocationError{Status: fiber.StatusBadRequest, Message: err.Error()}
+	}
+
+	// Fill in coordinates, address and city via the geocoder
+	warnings, err := resolveLocationGeography(ctx, req)
+	if err != nil {
+		return nil, nil, &LocationError{Status: fiber.StatusBadRequest, Message: err.Error()}
+	}
+
+	// Check for the same place already pinned nearby
+	duplicates, err := findDuplicateLocations(req.Name, *req.Latitude, *req.Longitude, &userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings = append(warnings, duplicateWarnings(duplicates)...)
+	if len(duplicates) > 0 && !req.Force {
+		return nil, nil, &LocationError{
+			Status:     fiber.StatusConflict,
+			Message:    "This place may already exist. Resubmit with force to create it anyway",
+			Duplicates: duplicates,
+			Warnings:   warnings,
+		}
 	}
 
-	// Create location
 	location := models.Location{
 		UserID:      userID,
 		Name:        req.Name,
 		Description: req.Description,
 		Category:    req.Category,
 		Address:     req.Address,
-		Latitude:    req.Latitude,
-		Longitude:   req.Longitude,
+		Latitude:    *req.Latitude,
+		Longitude:   *req.Longitude,
 		City:        req.City,
 		Rating:      req.Rating,
 		PriceLevel:  req.PriceLevel,
-		Tags:        req.Tags,
+		Tags:        tags,
 		ImageURL:    req.ImageURL,
 		WebsiteURL:  req.WebsiteURL,
+		Visibility:  req.Visibility,
+		OpeningHours: openingHours,
 	}
 
-	if err := database.DB.Create(&location).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create location",
-		})
+	if err := assignCity(&location); err != nil {
+		return nil, nil, err
 	}
 
-	// Load user info for response
-	database.DB.Preload("User").First(&location, location.ID)
+	return &location, warnings, nil
+}
 
-	return c.Status(fiber.StatusCreated).JSON(location)
+// saveNewLocation inserts a prepared location and records its first revision
+func saveNewLocation(location *models.Location, userID uuid.UUID) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(location).Error; err != nil {
+			return err
+		}
+		_, err := database.RecordLocationRevision(tx, location, userID, nil, nil)
+		return err
+	})
 }
 
 // UpdateLocation updates an existing location (owner only)
@@ -164,6 +401,7 @@ func UpdateLocation(c *fiber.Ctx) error {
 			"error": "You can only update your own locations",
 		})
 	}
+	before := models.SnapshotLocation(&location)
 
 	var req CreateLocationRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -193,11 +431,16 @@ func UpdateLocation(c *fiber.Ctx) error {
 	if req.City != "" {
 		location.City = req.City
 	}
-	if req.Latitude != 0 {
-		location.Latitude = req.Latitude
+	if req.Latitude != nil {
+		location.Latitude = *req.Latitude
 	}
-	if req.Longitude != 0 {
-		location.Longitude = req.Longitude
+	if req.Longitude != nil {
+		location.Longitude = *req.Longitude
+	}
+	if !geo.ValidCoordinates(location.Latitude, location.Longitude) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Coordinates are out of range",
+		})
 	}
 	if req.Rating != nil {
 		if *req.Rating < 1 || *req.Rating > 5 {
@@ -216,7 +459,13 @@ func UpdateLocation(c *fiber.Ctx) error {
 		location.PriceLevel = req.PriceLevel
 	}
 	if req.Tags != nil {
-		location.Tags = req.Tags
+		tags, err := database.CanonicalTags(req.Tags)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update location",
+			})
+		}
+		location.Tags = tags
 	}
 	if req.ImageURL != nil {
 		location.ImageURL = req.ImageURL
@@ -224,8 +473,38 @@ func UpdateLocation(c *fiber.Ctx) error {
 	if req.WebsiteURL != nil {
 		location.WebsiteURL = req.WebsiteURL
 	}
+	if req.Visibility != "" {
+		if !models.IsValidVisibility(req.Visibility) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Visibility must be public, unlisted, friends or private",
+			})
+		}
+		location.Visibility = req.Visibility
+	}
+	if req.OpeningHours != nil || req.OpeningHoursRules != nil {
+		openingHours, err := normalizeOpeningHours(req.OpeningHours, req.OpeningHoursRules)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		location.OpeningHours = openingHours
+	}
+
+	if err := assignCity(&location); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update location",
+		})
+	}
 
-	if err := database.DB.Save(&location).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&location).Error; err != nil {
+			return err
+		}
+		_, err := database.RecordLocationRevision(tx, &location, userID, &before, nil)
+		return err
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update location",
 		})
@@ -237,7 +516,7 @@ func UpdateLocation(c *fiber.Ctx) error {
 	return c.JSON(location)
 }
 
-// DeleteLocation deletes a location (owner only)
+// DeleteLocation moves a location to the trash (owner only)
 func DeleteLocation(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uuid.UUID)
 	locationID, err := uuid.Parse(c.Params("id"))
@@ -261,14 +540,21 @@ func DeleteLocation(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := database.DB.Delete(&location).Error; err != nil {
+	// Guides listing the location show its stop as deleted from now on
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := database.BumpGuidesListing(tx, []uuid.UUID{location.ID}); err != nil {
+			return err
+		}
+		return tx.Delete(&location).Error
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to delete location",
 		})
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "Location deleted successfully",
+		"message": "Location moved to trash",
 	})
 }
 
@@ -276,6 +562,13 @@ func DeleteLocation(c *fiber.Ctx) error {
 func GetUserLocations(c *fiber.Ctx) error {
 	username := c.Params("username")
 
+	format, err := exportFormat(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Format must be json, geojson, kml, gpx or csv",
+		})
+	}
+
 	// Find user by username
 	var user models.User
 	if err := database.DB.Where("username = ?", username).First(&user).Error; err != nil {
@@ -285,7 +578,19 @@ func GetUserLocations(c *fiber.Ctx) error {
 	}
 
 	var locations []models.Location
-	if err := database.DB.Preload("User").Where("user_id = ?", user.ID).Find(&locations).Error; err != nil {
+	query := visibleLocations(preloadCoverPhoto(database.DB.Preload("User")), optionalUserID(c))
+	if err := query.Where("user_id = ?", user.ID).Find(&locations).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch user locations",
+		})
+	}
+
+	if format != "" {
+		return sendLocationsExport(c, format, user.DisplayName+"'s places", locations)
+	}
+
+	responses, err := withSavedFlags(locations, optionalUserID(c))
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch user locations",
 		})
@@ -293,7 +598,68 @@ func GetUserLocations(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{
 		"user":      user,
+		"locations": responses,
+		"count":     len(responses),
+	})
+}
+
+// GetTrashedLocations returns the current user's deleted locations
+func GetTrashedLocations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var locations []models.Location
+	err := database.DB.Unscoped().Preload("User").
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Find(&locations).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch deleted locations",
+		})
+	}
+
+	return c.JSON(fiber.Map{
 		"locations": locations,
 		"count":     len(locations),
 	})
 }
+
+// RestoreLocation moves a deleted location out of the trash (owner only)
+func RestoreLocation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	locationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid location ID",
+		})
+	}
+
+	var location models.Location
+	if err := database.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&location, locationID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Deleted location not found",
+		})
+	}
+
+	if location.UserID != userID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You can only restore your own locations",
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := database.BumpGuidesListing(tx, []uuid.UUID{location.ID}); err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&location).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to restore location",
+		})
+	}
+
+	database.DB.Preload("User").First(&location, location.ID)
+
+	return c.JSON(location)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MergeLocationsRequest represents location merge payload
+type MergeLocationsRequest struct {
+	SourceID uuid.UUID `json:"source_id"`
+	TargetID uuid.UUID `json:"target_id"`
+}
+
+// MergeLocations merges a duplicate location into another (moderators and admins only)
+func MergeLocations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req MergeLocationsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.SourceID == uuid.Nil || req.TargetID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Source and target location IDs are required",
+		})
+	}
+	if req.SourceID == req.TargetID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A location cannot be merged into itself",
+		})
+	}
+
+	target, err := database.MergeLocations(req.SourceID, req.TargetID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Location not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to merge locations",
+		})
+	}
+
+	var location models.Location
+	database.DB.Preload("User").First(&location, target.ID)
+
+	return c.JSON(fiber.Map{
+		"message":  "Locations merged successfully",
+		"location": location,
+	})
+}
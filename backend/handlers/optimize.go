@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+	"myarea-backend/routing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// optimizeTimeout bounds the time spent improving a route; the best order
+// found by then is returned
+const optimizeTimeout = 2 * time.Second
+
+// OptimizeGuideRequest represents guide route optimization payload
+type OptimizeGuideRequest struct {
+	// Closed routes return to the first stop
+	Closed      bool       `json:"closed"`
+	StartItemID *uuid.UUID `json:"start_item_id"`
+	EndItemID   *uuid.UUID `json:"end_item_id"`
+
+	// PerDay optimizes each planned day on its own; Days splits one route into that many days
+	PerDay bool `json:"per_day"`
+	Days   int  `json:"days"`
+}
+
+// OptimizedRoute is the proposed order for a group of stops
+type OptimizedRoute struct {
+	Day            *int        `json:"day"`
+	ItemIDs        []uuid.UUID `json:"item_ids"`
+	DistanceMeters float64     `json:"distance_meters"`
+}
+
+// OptimizeGuideResponse is a proposed stop order. ItemIDs lists every stop and
+// can be sent to the reorder endpoint as-is to accept it.
+type OptimizeGuideResponse struct {
+	ItemIDs               []uuid.UUID      `json:"item_ids"`
+	TotalDistanceMeters   float64          `json:"total_distance_meters"`
+	CurrentDistanceMeters float64          `json:"current_distance_meters"`
+	Closed                bool             `json:"closed"`
+	Days                  []OptimizedRoute `json:"days,omitempty"`
+}
+
+// OptimizeGuide proposes an efficient visiting order for a guide's stops using
+// great-circle distances. Nothing is saved (owner or editors).
+func OptimizeGuide(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findEditableGuide(c, userID)
+	if err != nil {
+		return err
+	}
+
+	var req OptimizeGuideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.PerDay && req.Days > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Use either per_day or days, not both",
+		})
+	}
+	if req.Closed && req.EndItemID != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A closed route ends where it starts, so it can't have an end stop",
+		})
+	}
+	if req.StartItemID != nil && req.EndItemID != nil && *req.StartItemID == *req.EndItemID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Start and end stops must differ; use closed for a round trip",
+		})
+	}
+	if req.Days < 0 || req.Days > models.MaxTripDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Days must be between 1 and %d", models.MaxTripDays),
+		})
+	}
+
+	stops, err := guideStops(guide.ID, &userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to optimize guide",
+		})
+	}
+
+	// Only stops with a location can be routed
+	routable := make([]GuideStop, 0, len(stops))
+	for _, stop := range stops {
+		if stop.Location != nil {
+			routable = append(routable, stop)
+		}
+	}
+	for _, id := range []*uuid.UUID{req.StartItemID, req.EndItemID} {
+		if id != nil && stopIndex(routable, *id) < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Start and end stops must be stops of this guide",
+			})
+		}
+	}
+	if len(routable) > models.MaxGuideItems {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Guides with more than %d stops can't be optimized", models.MaxGuideItems),
+		})
+	}
+	if req.Days > len(routable) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "There are fewer stops than days",
+		})
+	}
+
+	var groups [][]GuideStop
+	if req.PerDay {
+		groups = groupStopsByDay(routable)
+	} else {
+		groups = [][]GuideStop{routable}
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), optimizeTimeout)
+	defer cancel()
+
+	response := OptimizeGuideResponse{ItemIDs: []uuid.UUID{}, Closed: req.Closed}
+	for _, group := range groups {
+		order, distance, current := optimizeStops(ctx, group, &req)
+		response.CurrentDistanceMeters += current
+
+		if req.Days > 1 {
+			response.Days = splitRouteIntoDays(group, order, req.Days, req.Closed)
+			for _, day := range response.Days {
+				response.TotalDistanceMeters += day.DistanceMeters
+			}
+		} else {
+			response.TotalDistanceMeters += distance
+			route := OptimizedRoute{ItemIDs: make([]uuid.UUID, len(order)), DistanceMeters: math.Round(distance)}
+			for i, index := range order {
+				route.ItemIDs[i] = group[index].ID
+			}
+			if req.PerDay {
+				route.Day = group[0].Day
+				response.Days = append(response.Days, route)
+			}
+		}
+		for _, index := range order {
+			response.ItemIDs = append(response.ItemIDs, group[index].ID)
+		}
+	}
+
+	// Keep stops that can't be routed at the end so the order covers every item
+	var allIDs []uuid.UUID
+	database.DB.Model(&models.GuideItem{}).Where("guide_id = ?", guide.ID).Order("position ASC, created_at ASC").Pluck("id", &allIDs)
+	included := make(map[uuid.UUID]bool, len(response.ItemIDs))
+	for _, id := range response.ItemIDs {
+		included[id] = true
+	}
+	for _, id := range allIDs {
+		if !included[id] {
+			response.ItemIDs = append(response.ItemIDs, id)
+		}
+	}
+
+	response.TotalDistanceMeters = math.Round(response.TotalDistanceMeters)
+	response.CurrentDistanceMeters = math.Round(response.CurrentDistanceMeters)
+	return c.JSON(response)
+}
+
+// optimizeStops solves one group of stops. It returns the order as indexes
+// into stops, its distance and the distance of the current order.
+func optimizeStops(ctx context.Context, stops []GuideStop, req *OptimizeGuideRequest) ([]int, float64, float64) {
+	points := make([]routing.Point, len(stops))
+	current := make([]int, len(stops))
+	for i, stop := range stops {
+		points[i] = routing.Point{Latitude: stop.Location.Latitude, Longitude: stop.Location.Longitude}
+		current[i] = i
+	}
+	dist := routing.GreatCircleMatrix(points)
+
+	opts := routing.TourOptions{Closed: req.Closed, Start: -1, End: -1}
+	if req.StartItemID != nil {
+		opts.Start = stopIndex(stops, *req.StartItemID)
+	}
+	if req.EndItemID != nil {
+		opts.End = stopIndex(stops, *req.EndItemID)
+	}
+	// When splitting into days, a closed route returns to the start each day instead
+	closed := req.Closed && req.Days <= 1
+	opts.Closed = closed
+
+	order, distance := routing.SolveTour(ctx, dist, opts)
+	return order, distance, routing.TourLength(dist, current, closed)
+}
+
+// splitRouteIntoDays cuts an optimized order into consecutive days with as
+// even a number of stops as possible
+func splitRouteIntoDays(stops []GuideStop, order []int, days int, closed bool) []OptimizedRoute {
+	points := make([]routing.Point, len(stops))
+	for i, stop := range stops {
+		points[i] = routing.Point{Latitude: stop.Location.Latitude, Longitude: stop.Location.Longitude}
+	}
+	dist := routing.GreatCircleMatrix(points)
+
+	routes := make([]OptimizedRoute, 0, days)
+	start := 0
+	for day := 1; day <= days; day++ {
+		size := (len(order) - start) / (days - day + 1)
+		chunk := order[start : start+size]
+		start += size
+
+		d := day
+		route := OptimizedRoute{Day: &d, ItemIDs: make([]uuid.UUID, len(chunk)), DistanceMeters: math.Round(routing.TourLength(dist, chunk, closed))}
+		for i, index := range chunk {
+			route.ItemIDs[i] = stops[index].ID
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// groupStopsByDay splits stops by planned day, in day order, with unscheduled stops last
+func groupStopsByDay(stops []GuideStop) [][]GuideStop {
+	byDay := map[int][]GuideStop{}
+	var unscheduled []GuideStop
+	maxDay := 0
+	for _, stop := range stops {
+		if stop.Day == nil {
+			unscheduled = append(unscheduled, stop)
+			continue
+		}
+		byDay[*stop.Day] = append(byDay[*stop.Day], stop)
+		if *stop.Day > maxDay {
+			maxDay = *stop.Day
+		}
+	}
+
+	var groups [][]GuideStop
+	for day := 1; day <= maxDay; day++ {
+		if len(byDay[day]) > 0 {
+			groups = append(groups, byDay[day])
+		}
+	}
+	if len(unscheduled) > 0 {
+		groups = append(groups, unscheduled)
+	}
+	return groups
+}
+
+// stopIndex returns the index of the stop with the given item ID, or -1
+func stopIndex(stops []GuideStop, itemID uuid.UUID) int {
+	for i, stop := range stops {
+		if stop.ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
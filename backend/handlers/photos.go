@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"myarea-backend/database"
+	"myarea-backend/media"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReorderPhotosRequest represents gallery reorder payload
+type ReorderPhotosRequest struct {
+	PhotoIDs []uuid.UUID `json:"photo_ids"`
+}
+
+// UpdatePhotoRequest represents photo update payload
+type UpdatePhotoRequest struct {
+	Caption *string `json:"caption"`
+}
+
+// GetLocationPhotos returns a location's gallery in order
+func GetLocationPhotos(c *fiber.Ctx) error {
+	location, err := findViewableLocation(c)
+	if err != nil {
+		return err
+	}
+
+	var photos []models.LocationPhoto
+	if err := database.DB.Preload("Image").Preload("Uploader").Where("location_id = ?", location.ID).Order("position ASC").Find(&photos).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch photos",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"photos": photos,
+		"count":  len(photos),
+	})
+}
+
+// AddLocationPhoto uploads a photo to the end of a location's gallery.
+// Anyone who can see the location may contribute photos.
+func AddLocationPhoto(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	location, err := findViewableLocation(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := readUploadedImage(c, "image")
+	if err != nil {
+		return respondUploadError(c, err)
+	}
+
+	var caption *string
+	if v := strings.TrimSpace(c.FormValue("caption")); v != "" {
+		caption = &v
+	}
+
+	photo, err := addLocationPhoto(c.UserContext(), location, userID, data, caption, false)
+	if err != nil {
+		return respondUploadError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(photo)
+}
+
+// UpdateLocationPhoto edits a photo's caption (uploader or location owner)
+func UpdateLocationPhoto(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	location, photo, err := findEditablePhoto(c, userID)
+	if err != nil {
+		return err
+	}
+
+	var req UpdatePhotoRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Caption != nil {
+		caption := strings.TrimSpace(*req.Caption)
+		if caption == "" {
+			photo.Caption = nil
+		} else {
+			photo.Caption = &caption
+		}
+	}
+
+	if err := database.DB.Omit("Image", "Uploader").Save(photo).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update photo",
+		})
+	}
+
+	database.DB.Preload("Image").Preload("Uploader").Where("location_id = ?", location.ID).First(photo, photo.ID)
+
+	return c.JSON(photo)
+}
+
+// ReorderLocationPhotos sets the gallery order; the first photo becomes the cover (owner only)
+func ReorderLocationPhotos(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	locationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid location ID",
+		})
+	}
+
+	var location models.Location
+	if err := database.DB.First(&location, locationID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Location not found",
+		})
+	}
+	if location.UserID != userID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You can only reorder photos of your own locations",
+		})
+	}
+
+	var req ReorderPhotosRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockLocationPhotos(tx, location.ID); err != nil {
+			return err
+		}
+		// The new order must name every photo exactly once
+		var ids []uuid.UUID
+		if err := tx.Model(&models.LocationPhoto{}).Where("location_id = ?", location.ID).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if !samePermutation(ids, req.PhotoIDs) {
+			return fiber.NewError(fiber.StatusBadRequest, "Photo IDs must list each photo of this location exactly once")
+		}
+		for position, id := range req.PhotoIDs {
+			if err := tx.Model(&models.LocationPhoto{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return syncCoverImage(tx, location.ID, false)
+	})
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reorder photos",
+		})
+	}
+
+	return GetLocationPhotos(c)
+}
+
+// DeleteLocationPhoto removes a photo from the gallery (uploader or location owner)
+func DeleteLocationPhoto(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	location, photo, err := findEditablePhoto(c, userID)
+	if err != nil {
+		return err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockLocationPhotos(tx, location.ID); err != nil {
+			return err
+		}
+		// Read the position again now that nothing else can move it
+		var current models.LocationPhoto
+		if err := tx.Select("id", "position").First(&current, photo.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.LocationPhoto{}, photo.ID).Error; err != nil {
+			return err
+		}
+		// Close the gap left by the removed photo
+		if err := tx.Model(&models.LocationPhoto{}).
+			Where("location_id = ? AND position > ?", location.ID, current.Position).
+			Update("position", gorm.Expr("position - 1")).Error; err != nil {
+			return err
+		}
+		return syncCoverImage(tx, location.ID, false)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete photo",
+		})
+	}
+
+	deleteImage(c.UserContext(), &photo.Image)
+
+	return c.JSON(fiber.Map{
+		"message": "Photo deleted successfully",
+	})
+}
+
+// addLocationPhoto stores an upload and inserts it into the gallery, either
+// as the new cover or at the end
+func addLocationPhoto(ctx context.Context, location *models.Location, uploaderID uuid.UUID, data []byte, caption *string, asCover bool) (*models.LocationPhoto, error) {
+	image, err := storeImage(ctx, uploaderID, models.ImageKindLocation, location.ID, data, media.LocationVariants)
+	if err != nil {
+		return nil, err
+	}
+
+	photo := models.LocationPhoto{
+		LocationID: location.ID,
+		ImageID:    image.ID,
+		Caption:    caption,
+		UploaderID: uploaderID,
+		Width:      image.Width,
+		Height:     image.Height,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockLocationPhotos(tx, location.ID); err != nil {
+			return err
+		}
+		if asCover {
+			if err := tx.Model(&models.LocationPhoto{}).
+				Where("location_id = ?", location.ID).
+				Update("position", gorm.Expr("position + 1")).Error; err != nil {
+				return err
+			}
+			photo.Position = 0
+		} else {
+			var count int64
+			if err := tx.Model(&models.LocationPhoto{}).Where("location_id = ?", location.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			photo.Position = int(count)
+		}
+		if err := tx.Omit("Image", "Uploader").Create(&photo).Error; err != nil {
+			return err
+		}
+		return syncCoverImage(tx, location.ID, asCover)
+	})
+	if err != nil {
+		deleteImage(ctx, image)
+		return nil, err
+	}
+
+	database.DB.Preload("Image").Preload("Uploader").First(&photo, photo.ID)
+	return &photo, nil
+}
+
+// lockLocationPhotos locks the location row so gallery changes to it run one
+// at a time and photo positions stay unique
+func lockLocationPhotos(tx *gorm.DB, locationID uuid.UUID) error {
+	var locked models.Location
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, locationID).Error
+}
+
+// preloadCoverPhoto loads just the cover photo of each location
+func preloadCoverPhoto(query *gorm.DB) *gorm.DB {
+	return query.Preload("CoverPhoto", "position = 0").Preload("CoverPhoto.Image")
+}
+
+// syncCoverImage points the location's image_url at its cover photo. An
+// image_url the owner entered by hand is left alone unless claim is set, so
+// only URLs that came from the gallery are replaced or cleared.
+func syncCoverImage(tx *gorm.DB, locationID uuid.UUID, claim bool) error {
+	if !claim {
+		fromGallery, err := hasGalleryImageURL(tx, locationID)
+		if err != nil || !fromGallery {
+			return err
+		}
+	}
+
+	var cover models.LocationPhoto
+	err := tx.Preload("Image").Where("location_id = ?", locationID).Order("position ASC").First(&cover).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Model(&models.Location{}).Where("id = ?", locationID).Update("image_url", nil).Error
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.Location{}).Where("id = ?", locationID).Update("image_url", cover.Image.URL("large")).Error
+}
+
+// hasGalleryImageURL reports whether the location's image_url is empty or
+// points at one of its own gallery images
+func hasGalleryImageURL(tx *gorm.DB, locationID uuid.UUID) (bool, error) {
+	var location models.Location
+	if err := tx.Select("id", "image_url").First(&location, locationID).Error; err != nil {
+		return false, err
+	}
+	if location.ImageURL == nil || *location.ImageURL == "" {
+		return true, nil
+	}
+
+	var images []models.Image
+	if err := tx.Where("kind = ? AND subject_id = ?", models.ImageKindLocation, locationID).Find(&images).Error; err != nil {
+		return false, err
+	}
+	for _, image := range images {
+		for _, v := range image.Variants {
+			if v.URL == *location.ImageURL {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// findViewableLocation loads the :id location if the caller may see it. Errors
+// are *fiber.Error values rendered by the app's error handler.
+func findViewableLocation(c *fiber.Ctx) (*models.Location, error) {
+	locationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid location ID")
+	}
+
+	var location models.Location
+	if err := viewableLocations(database.DB, optionalUserID(c)).First(&location, locationID).Error; err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Location not found")
+	}
+	return &location, nil
+}
+
+// findEditablePhoto loads the :photoId photo of the :id location if the
+// caller uploaded it or owns the location
+func findEditablePhoto(c *fiber.Ctx, userID uuid.UUID) (*models.Location, *models.LocationPhoto, error) {
+	location, err := findViewableLocation(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	photoID, err := uuid.Parse(c.Params("photoId"))
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Invalid photo ID")
+	}
+
+	var photo models.LocationPhoto
+	if err := database.DB.Preload("Image").Where("location_id = ?", location.ID).First(&photo, photoID).Error; err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusNotFound, "Photo not found")
+	}
+
+	if photo.UploaderID != userID && location.UserID != userID {
+		return nil, nil, fiber.NewError(fiber.StatusForbidden, "You can only change your own photos")
+	}
+	return location, &photo, nil
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/png"
+	"strings"
+	"time"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+	"myarea-backend/pdf"
+	"myarea-backend/staticmap"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Overview map size in pixels
+const (
+	printMapWidth  = 1000
+	printMapHeight = 560
+)
+
+// guidePrintout is a guide prepared for printing
+type guidePrintout struct {
+	Title       string
+	Description string
+	Meta        []string
+	Map         image.Image
+	Stops       []printedStop
+	Generated   string
+}
+
+// printedStop is one numbered stop in a printout
+type printedStop struct {
+	Number   int
+	Name     string
+	Category string
+	Address  string
+	Schedule string
+	Hours    string
+	Note     string
+	Website  string
+}
+
+// GetGuideExport renders a guide for printing as ?format=pdf or html (default)
+func GetGuideExport(c *fiber.Ctx) error {
+	guideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid guide ID",
+		})
+	}
+
+	viewerID := optionalUserID(c)
+	var guide models.Guide
+	if err := visibleGuides(database.DB.Preload("User"), viewerID).First(&guide, guideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Guide not found",
+		})
+	}
+
+	return sendGuidePrintout(c, &guide, viewerID)
+}
+
+// GetSharedGuideExport renders a guide opened through a share link for printing
+func GetSharedGuideExport(c *fiber.Ctx) error {
+	guide, _, err := findSharedGuide(c)
+	if err != nil {
+		return err
+	}
+	return sendGuidePrintout(c, guide, optionalUserID(c))
+}
+
+// sendGuidePrintout renders the guide in the requested format
+func sendGuidePrintout(c *fiber.Ctx, guide *models.Guide, viewerID *uuid.UUID) error {
+	format := strings.ToLower(c.Query("format", "html"))
+	if format != "html" && format != "pdf" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Format must be pdf or html",
+		})
+	}
+
+	stops, err := guideStops(guide.ID, viewerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export guide",
+		})
+	}
+	printout := buildPrintout(guide, stops)
+
+	filename := models.NormalizeTag(guide.Title)
+	if filename == "" {
+		filename = "guide"
+	}
+
+	var buf bytes.Buffer
+	if format == "pdf" {
+		err = writeGuidePDF(&buf, printout)
+		c.Set(fiber.HeaderContentType, "application/pdf")
+	} else {
+		err = writeGuideHTML(&buf, printout)
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export guide",
+		})
+	}
+
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s.%s"`, filename, format))
+	return c.Send(buf.Bytes())
+}
+
+// buildPrintout collects what a printout shows. Stops in the trash are left
+// out and the rest are numbered in the curator's order, matching the map.
+func buildPrintout(guide *models.Guide, stops []GuideStop) *guidePrintout {
+	printout := &guidePrintout{
+		Title:     guide.Title,
+		Generated: time.Now().Format("January 2, 2006"),
+	}
+	if guide.Description != nil {
+		printout.Description = *guide.Description
+	}
+	if guide.City != "" {
+		printout.Meta = append(printout.Meta, guide.City)
+	}
+	if guide.User.DisplayName != "" {
+		printout.Meta = append(printout.Meta, "By "+guide.User.DisplayName)
+	}
+	if guide.StartDate != nil && guide.EndDate != nil {
+		printout.Meta = append(printout.Meta, guide.StartDate.Format("Jan 2, 2006")+" to "+guide.EndDate.Format("Jan 2, 2006"))
+	}
+
+	var points []staticmap.Point
+	for _, stop := range stops {
+		location := stop.Location
+		if location == nil {
+			continue
+		}
+		points = append(points, staticmap.Point{Latitude: location.Latitude, Longitude: location.Longitude})
+
+		printed := printedStop{
+			Number:   len(points),
+			Name:     location.Name,
+			Category: strings.ReplaceAll(location.Category, "-", " "),
+			Address:  location.Address,
+			Schedule: stopSchedule(&stop.GuideItem),
+		}
+		if schedule := location.Schedule(); schedule != nil {
+			printed.Hours = schedule.String()
+		}
+		if stop.Note != nil {
+			printed.Note = *stop.Note
+		}
+		if location.WebsiteURL != nil {
+			printed.Website = *location.WebsiteURL
+		}
+		printout.Stops = append(printout.Stops, printed)
+	}
+
+	if len(points) > 0 {
+		printout.Map = staticmap.Render(points, staticmap.Options{Width: printMapWidth, Height: printMapHeight, Route: true})
+	}
+	return printout
+}
+
+// stopSchedule describes when a stop is planned, e.g. "Day 2, morning, 10:00 for 90 min"
+func stopSchedule(item *models.GuideItem) string {
+	var parts []string
+	if item.Day != nil {
+		parts = append(parts, fmt.Sprintf("Day %d", *item.Day))
+	}
+	if item.Slot != nil {
+		parts = append(parts, *item.Slot)
+	}
+	if item.StartTime != nil {
+		at := *item.StartTime
+		if item.DurationMinutes != nil {
+			at += fmt.Sprintf(" for %d min", *item.DurationMinutes)
+		}
+		parts = append(parts, at)
+	}
+	return strings.Join(parts, ", ")
+}
+
+var guideHTMLTemplate = template.Must(template.New("guide").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: Helvetica, Arial, sans-serif; color: #111; max-width: 760px; margin: 32px auto; padding: 0 16px; }
+  h1 { margin-bottom: 4px; }
+  .meta, .details, .generated { color: #555; font-size: 0.9em; }
+  .description { white-space: pre-line; }
+  .map { width: 100%; border: 1px solid #9ca3af; margin: 16px 0; }
+  ol { padding-left: 0; list-style: none; }
+  li { break-inside: avoid; page-break-inside: avoid; border-top: 1px solid #ddd; padding: 10px 0; }
+  .number { display: inline-block; width: 24px; height: 24px; border-radius: 12px; background: #dc2626; color: #fff; text-align: center; line-height: 24px; font-weight: bold; font-size: 0.85em; margin-right: 6px; }
+  .name { font-weight: bold; font-size: 1.1em; }
+  .note { font-style: italic; white-space: pre-line; margin: 4px 0; }
+  a { color: #1d4ed8; }
+  @media print { body { margin: 0; } a { color: inherit; } }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Meta}}<div class="meta">{{range $i, $m := .}}{{if $i}} · {{end}}{{$m}}{{end}}</div>{{end}}
+{{with .Description}}<p class="description">{{.}}</p>{{end}}
+{{with .MapURI}}<img class="map" src="{{.}}" alt="Map of the stops">{{end}}
+<ol>
+{{range .Stops}}<li>
+  <div><span class="number">{{.Number}}</span><span class="name">{{.Name}}</span></div>
+  <div class="details">{{.Category}}{{with .Address}} · {{.}}{{end}}</div>
+  {{with .Schedule}}<div class="details">{{.}}</div>{{end}}
+  {{with .Hours}}<div class="details">Hours: {{.}}</div>{{end}}
+  {{with .Note}}<div class="note">{{.}}</div>{{end}}
+  {{with .Website}}<div class="details"><a href="{{.}}">{{.}}</a></div>{{end}}
+</li>
+{{end}}</ol>
+<p class="generated">Printed from MyArea on {{.Generated}}</p>
+</body>
+</html>
+`))
+
+// writeGuideHTML renders a standalone page with the map inlined as a PNG
+func writeGuideHTML(buf *bytes.Buffer, printout *guidePrintout) error {
+	data := struct {
+		*guidePrintout
+		MapURI template.URL
+	}{guidePrintout: printout}
+
+	if printout.Map != nil {
+		var img bytes.Buffer
+		if err := png.Encode(&img, printout.Map); err != nil {
+			return err
+		}
+		data.MapURI = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(img.Bytes()))
+	}
+	return guideHTMLTemplate.Execute(buf, data)
+}
+
+// writeGuidePDF lays the printout out on A4 pages
+func writeGuidePDF(buf *bytes.Buffer, printout *guidePrintout) error {
+	const margin = 48.0
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	doc.SetTitle(printout.Title)
+	width := doc.Width() - 2*margin
+	bottom := doc.Height() - margin
+
+	page := doc.AddPage()
+	y := margin
+
+	// ensure starts a new page unless height more points fit on this one
+	ensure := func(height float64) {
+		if y+height > bottom {
+			page = doc.AddPage()
+			y = margin
+		}
+	}
+	// paragraph writes wrapped text, breaking pages between lines
+	paragraph := func(text string, font pdf.Font, size float64, color pdf.Color, indent float64) {
+		leading := size * 1.35
+		for _, line := range pdf.Wrap(font, size, text, width-indent) {
+			ensure(leading)
+			y += leading
+			page.Text(margin+indent, y-size*0.3, font, size, line, color)
+		}
+	}
+	gray := pdf.Gray(0.35)
+	link := pdf.Color{R: 0.11, G: 0.31, B: 0.85}
+
+	paragraph(printout.Title, pdf.HelveticaBold, 22, pdf.Black, 0)
+	if len(printout.Meta) > 0 {
+		y += 2
+		paragraph(strings.Join(printout.Meta, "  |  "), pdf.Helvetica, 10, gray, 0)
+	}
+	if printout.Description != "" {
+		y += 8
+		paragraph(printout.Description, pdf.Helvetica, 11, pdf.Black, 0)
+	}
+
+	if printout.Map != nil {
+		bounds := printout.Map.Bounds()
+		height := width * float64(bounds.Dy()) / float64(bounds.Dx())
+		y += 12
+		ensure(height)
+		if err := page.Image(printout.Map, margin, y, width, height); err != nil {
+			return err
+		}
+		y += height + 8
+	}
+
+	const indent = 26.0
+	for _, stop := range printout.Stops {
+		// Keep at least the name and first details line together
+		y += 10
+		ensure(40)
+		page.Line(margin, y, margin+width, y, 0.5, pdf.Gray(0.8))
+		y += 6
+
+		number := fmt.Sprint(stop.Number)
+		page.Rect(margin, y+2, 18, 16, pdf.Color{R: 0.86, G: 0.15, B: 0.15})
+		page.Text(margin+9-pdf.TextWidth(pdf.HelveticaBold, 9, number)/2, y+13, pdf.HelveticaBold, 9, number, pdf.Gray(1))
+		paragraph(stop.Name, pdf.HelveticaBold, 13, pdf.Black, indent)
+
+		details := stop.Category
+		if stop.Address != "" {
+			details += "  |  " + stop.Address
+		}
+		paragraph(details, pdf.Helvetica, 10, gray, indent)
+		if stop.Schedule != "" {
+			paragraph(stop.Schedule, pdf.Helvetica, 10, gray, indent)
+		}
+		if stop.Hours != "" {
+			paragraph("Hours: "+stop.Hours, pdf.Helvetica, 10, gray, indent)
+		}
+		if stop.Note != "" {
+			y += 2
+			paragraph(stop.Note, pdf.HelveticaOblique, 10.5, pdf.Black, indent)
+		}
+		if stop.Website != "" {
+			paragraph(stop.Website, pdf.Helvetica, 9.5, link, indent)
+		}
+	}
+
+	y += 18
+	paragraph("Printed from MyArea on "+printout.Generated, pdf.Helvetica, 8.5, gray, 0)
+
+	pages := doc.Pages()
+	for i, p := range pages {
+		footer := fmt.Sprintf("%d / %d", i+1, len(pages))
+		p.Text(doc.Width()-margin-pdf.TextWidth(pdf.Helvetica, 8.5, footer), doc.Height()-margin/2, pdf.Helvetica, 8.5, footer, gray)
+	}
+	return doc.Write(buf)
+}
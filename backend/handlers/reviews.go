@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReviewRequest represents review create and update payload
+type ReviewRequest struct {
+	Rating    *int    `json:"rating"`
+	Text      *string `json:"text"`
+	VisitedOn *string `json:"visited_on"`
+}
+
+// GetLocationReviews returns a page of a location's reviews, newest first
+func GetLocationReviews(c *fiber.Ctx) error {
+	location, err := findViewableLocation(c)
+	if err != nil {
+		return err
+	}
+
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var reviews []models.Review
+	err = database.DB.Preload("User").
+		Where("location_id = ?", location.ID).
+		Order("created_at DESC, id").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&reviews).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch reviews",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"reviews":        reviews,
+		"count":          len(reviews),
+		"total":          location.ReviewCount,
+		"page":           page,
+		"limit":          limit,
+		"average_rating": location.AverageRating,
+	})
+}
+
+// CreateReview adds the current user's review of a location
+func CreateReview(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	location, err := findViewableLocation(c)
+	if err != nil {
+		return err
+	}
+
+	var req ReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.Rating == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Rating is required",
+		})
+	}
+
+	review := models.Review{LocationID: location.ID, UserID: userID}
+	if err := applyReviewRequest(&review, &req); err != nil {
+		return err
+	}
+
+	var existing int64
+	database.DB.Model(&models.Review{}).Where("location_id = ? AND user_id = ?", location.ID, userID).Count(&existing)
+	if existing > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "You have already reviewed this location",
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+		return database.RefreshLocationRating(tx, location.ID)
+	})
+	// The unique index catches a concurrent request that passed the check above
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "You have already reviewed this location",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create review",
+		})
+	}
+
+	database.DB.Preload("User").First(&review, review.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(review)
+}
+
+// UpdateReview edits a review (author only)
+func UpdateReview(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	review, err := findReview(c)
+	if err != nil {
+		return err
+	}
+
+	if review.UserID != userID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You can only edit your own reviews",
+		})
+	}
+
+	var req ReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := applyReviewRequest(review, &req); err != nil {
+		return err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(review).Error; err != nil {
+			return err
+		}
+		return database.RefreshLocationRating(tx, review.LocationID)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update review",
+		})
+	}
+
+	database.DB.Preload("User").First(review, review.ID)
+
+	return c.JSON(review)
+}
+
+// DeleteReview removes a review (author, moderators and admins)
+func DeleteReview(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	review, err := findReview(c)
+	if err != nil {
+		return err
+	}
+
+	if review.UserID != userID && !isModerator(userID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You can only delete your own reviews",
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(review).Error; err != nil {
+			return err
+		}
+		return database.RefreshLocationRating(tx, review.LocationID)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete review",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Review deleted successfully",
+	})
+}
+
+// applyReviewRequest validates the request and copies its fields onto review
+func applyReviewRequest(review *models.Review, req *ReviewRequest) error {
+	if req.Rating != nil {
+		if *req.Rating < 1 || *req.Rating > 5 {
+			return fiber.NewError(fiber.StatusBadRequest, "Rating must be between 1 and 5")
+		}
+		review.Rating = *req.Rating
+	}
+
+	if req.Text != nil {
+		text := strings.TrimSpace(*req.Text)
+		if len([]rune(text)) > models.MaxReviewLength {
+			return fiber.NewError(fiber.StatusBadRequest, "Review text is too long")
+		}
+		if text == "" {
+			review.Text = nil
+		} else {
+			review.Text = &text
+		}
+	}
+
+	if req.VisitedOn != nil {
+		if *req.VisitedOn == "" {
+			review.VisitedOn = nil
+		} else {
+			visited, err := time.Parse("2006-01-02", *req.VisitedOn)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Visit date must be formatted as YYYY-MM-DD")
+			}
+			if visited.After(time.Now()) {
+				return fiber.NewError(fiber.StatusBadRequest, "Visit date can't be in the future")
+			}
+			review.VisitedOn = &visited
+		}
+	}
+	return nil
+}
+
+// findReview loads the review named in the route, checking that its location
+// is viewable by the caller
+func findReview(c *fiber.Ctx) (*models.Review, error) {
+	location, err := findViewableLocation(c)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewID, err := uuid.Parse(c.Params("reviewId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid review ID")
+	}
+
+	var review models.Review
+	err = database.DB.Where("location_id = ?", location.ID).First(&review, reviewID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Review not found")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch review")
+	}
+	return &review, nil
+}
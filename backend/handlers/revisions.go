@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"strconv"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetLocationRevisions returns the edit history of a location, newest first.
+// Old snapshots can hold details the owner has since removed, so the history
+// is limited to the owner, moderators and admins.
+func GetLocationRevisions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	locationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid location ID",
+		})
+	}
+
+	var location models.Location
+	if err := database.DB.First(&location, locationID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Location not found",
+		})
+	}
+
+	if location.UserID != userID && !isModerator(userID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the owner and moderators can view the edit history",
+		})
+	}
+
+	var revisions []models.LocationRevision
+	err = database.DB.Preload("Author").
+		Where("location_id = ?", location.ID).
+		Order("number DESC").
+		Find(&revisions).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch revisions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"revisions": revisions,
+		"count":     len(revisions),
+	})
+}
+
+// RestoreLocationRevision rolls a location back to the state saved in a
+// revision (owner only). The rollback is itself recorded as a new revision.
+func RestoreLocationRevision(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	locationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid location ID",
+		})
+	}
+	number, err := strconv.Atoi(c.Params("rev"))
+	if err != nil || number < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid revision number",
+		})
+	}
+
+	var location models.Location
+	if err := database.DB.First(&location, locationID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Location not found",
+		})
+	}
+
+	if location.UserID != userID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You can only restore your own locations",
+		})
+	}
+
+	var revision models.LocationRevision
+	if err := database.DB.Where("location_id = ? AND number = ?", location.ID, number).First(&revision).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Revision not found",
+		})
+	}
+
+	if !models.IsValidCategory(revision.Snapshot.Category) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The category in this revision no longer exists",
+		})
+	}
+
+	before := models.SnapshotLocation(&location)
+	revision.Snapshot.ApplyTo(&location)
+
+	if err := assignCity(&location); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to restore revision",
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&location).Error; err != nil {
+			return err
+		}
+		_, err := database.RecordLocationRevision(tx, &location, userID, &before, &revision.Number)
+		return err
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to restore revision",
+		})
+	}
+
+	database.DB.Preload("User").First(&location, location.ID)
+
+	return c.JSON(location)
+}
+
+// isModerator reports whether the user is a moderator or admin
+func isModerator(userID uuid.UUID) bool {
+	var user models.User
+	if err := database.DB.Select("id", "role").First(&user, userID).Error; err != nil {
+		return false
+	}
+	return user.Role == models.RoleModerator || user.Role == models.RoleAdmin
+}
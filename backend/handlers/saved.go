@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SaveLocationRequest represents save location payload
+type SaveLocationRequest struct {
+	LocationID uuid.UUID  `json:"location_id"`
+	ListID     *uuid.UUID `json:"list_id"`
+}
+
+// SavedListRequest represents saved list create and rename payload
+type SavedListRequest struct {
+	Name string `json:"name"`
+}
+
+// SavedListResponse is a saved list with the number of locations in it
+type SavedListResponse struct {
+	models.SavedList
+	Count int64 `json:"count"`
+}
+
+// GetSavedLocations returns the current user's saved locations, newest first.
+// ?list=<id> limits them to one list and ?list=none to saves outside any list.
+func GetSavedLocations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	query := database.DB.Where("user_id = ?", userID)
+	switch list := c.Query("list"); list {
+	case "":
+	case "none":
+		query = query.Where("list_id IS NULL")
+	default:
+		listID, err := uuid.Parse(list)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid list ID",
+			})
+		}
+		query = query.Where("list_id = ?", listID)
+	}
+
+	var saves []models.SavedLocation
+	if err := query.Order("created_at DESC").Find(&saves).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch saved locations",
+		})
+	}
+
+	// Attach locations the user can still see; deleted or hidden ones are left out
+	ids := make([]uuid.UUID, 0, len(saves))
+	for _, save := range saves {
+		ids = append(ids, save.LocationID)
+	}
+	var locations []models.Location
+	if len(ids) > 0 {
+		err := viewableLocations(preloadCoverPhoto(database.DB.Preload("User")), &userID).
+			Where("locations.id IN ?", ids).
+			Find(&locations).Error
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch saved locations",
+			})
+		}
+	}
+	byID := make(map[uuid.UUID]*models.Location, len(locations))
+	for i := range locations {
+		byID[locations[i].ID] = &locations[i]
+	}
+
+	result := make([]models.SavedLocation, 0, len(saves))
+	for _, save := range saves {
+		if location, ok := byID[save.LocationID]; ok {
+			save.Location = location
+			result = append(result, save)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"saved": result,
+		"count": len(result),
+	})
+}
+
+// SaveLocation bookmarks a location for the current user, optionally in a list
+func SaveLocation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req SaveLocationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var location models.Location
+	if err := viewableLocations(database.DB, &userID).First(&location, req.LocationID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Location not found",
+		})
+	}
+
+	query := database.DB.Where("user_id = ? AND location_id = ?", userID, location.ID)
+	if req.ListID != nil {
+		if _, err := findSavedList(*req.ListID, userID); err != nil {
+			return err
+		}
+		query = query.Where("list_id = ?", *req.ListID)
+	} else {
+		query = query.Where("list_id IS NULL")
+	}
+	// Reusable for the lookup after a conflicting insert
+	query = query.Session(&gorm.Session{})
+
+	var save models.SavedLocation
+	err := query.First(&save).Error
+	if err == nil {
+		return c.JSON(save)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save location",
+		})
+	}
+
+	save = models.SavedLocation{UserID: userID, LocationID: location.ID, ListID: req.ListID}
+	if err := database.DB.Create(&save).Error; err != nil {
+		// A concurrent request saved it first
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			var existing models.SavedLocation
+			if err := query.First(&existing).Error; err == nil {
+				return c.JSON(existing)
+			}
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save location",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(save)
+}
+
+// UnsaveLocation removes a saved location. With ?list=<id> it is removed from
+// that list only, otherwise from everywhere it was saved.
+func UnsaveLocation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	locationID, err := uuid.Parse(c.Params("locationId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid location ID",
+		})
+	}
+
+	query := database.DB.Where("user_id = ? AND location_id = ?", userID, locationID)
+	if list := c.Query("list"); list != "" {
+		listID, err := uuid.Parse(list)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid list ID",
+			})
+		}
+		query = query.Where("list_id = ?", listID)
+	}
+
+	result := query.Delete(&models.SavedLocation{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to unsave location",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Location is not saved",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Location unsaved successfully",
+	})
+}
+
+// GetSavedLists returns the current user's lists with their sizes
+func GetSavedLists(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var lists []SavedListResponse
+	err := database.DB.Model(&models.SavedList{}).
+		Select("saved_lists.*, (SELECT COUNT(*) FROM saved_locations WHERE saved_locations.list_id = saved_lists.id) AS count").
+		Where("user_id = ?", userID).
+		Order("name ASC").
+		Scan(&lists).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch lists",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"lists": lists,
+		"count": len(lists),
+	})
+}
+
+// CreateSavedList adds a named list for the current user
+func CreateSavedList(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	name, err := parseSavedListName(c)
+	if err != nil {
+		return err
+	}
+
+	if savedListNameTaken(userID, name, nil) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "You already have a list with that name",
+		})
+	}
+
+	list := models.SavedList{UserID: userID, Name: name}
+	if err := database.DB.Create(&list).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create list",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(list)
+}
+
+// UpdateSavedList renames a list (owner only)
+func UpdateSavedList(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	listID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid list ID",
+		})
+	}
+
+	list, err := findSavedList(listID, userID)
+	if err != nil {
+		return err
+	}
+
+	name, err := parseSavedListName(c)
+	if err != nil {
+		return err
+	}
+	if savedListNameTaken(userID, name, &list.ID) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "You already have a list with that name",
+		})
+	}
+
+	list.Name = name
+	if err := database.DB.Save(list).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update list",
+		})
+	}
+
+	return c.JSON(list)
+}
+
+// DeleteSavedList removes a list and the saves in it (owner only)
+func DeleteSavedList(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	listID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid list ID",
+		})
+	}
+
+	list, err := findSavedList(listID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("list_id = ?", list.ID).Delete(&models.SavedLocation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(list).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete list",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "List deleted successfully",
+	})
+}
+
+// findSavedList loads one of the user's lists
+func findSavedList(listID, userID uuid.UUID) (*models.SavedList, error) {
+	var list models.SavedList
+	if err := database.DB.Where("user_id = ?", userID).First(&list, listID).Error; err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "List not found")
+	}
+	return &list, nil
+}
+
+// parseSavedListName reads and validates the list name from the request body
+func parseSavedListName(c *fiber.Ctx) (string, error) {
+	var req SavedListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return "", fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	name := strings.Join(strings.Fields(req.Name), " ")
+	if name == "" {
+		return "", fiber.NewError(fiber.StatusBadRequest, "List name is required")
+	}
+	if len([]rune(name)) > models.MaxSavedListName {
+		return "", fiber.NewError(fiber.StatusBadRequest, "List name is too long")
+	}
+	return name, nil
+}
+
+// savedListNameTaken reports whether the user has another list with this name
+func savedListNameTaken(userID uuid.UUID, name string, except *uuid.UUID) bool {
+	query := database.DB.Model(&models.SavedList{}).Where("user_id = ? AND LOWER(name) = LOWER(?)", userID, name)
+	if except != nil {
+		query = query.Where("id <> ?", *except)
+	}
+	var count int64
+	query.Count(&count)
+	return count > 0
+}
+
+// withSavedFlags wraps locations for a listing, marking which ones the viewer
+// has saved. is_saved is left out for anonymous viewers.
+func withSavedFlags(locations []models.Location, viewerID *uuid.UUID) ([]LocationResponse, error) {
+	responses := make([]LocationResponse, len(locations))
+	for i := range locations {
+		responses[i].Location = locations[i]
+	}
+	if viewerID == nil {
+		return responses, nil
+	}
+
+	ids := make([]uuid.UUID, len(locations))
+	for i := range locations {
+		ids[i] = locations[i].ID
+	}
+	saved, err := database.SavedLocationIDs(*viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range responses {
+		isSaved := saved[responses[i].ID]
+		responses[i].IsSaved = &isSaved
+	}
+	return responses, nil
+}
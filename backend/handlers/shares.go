@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GuideShareRequest represents share link create payload
+type GuideShareRequest struct {
+	Label      *string `json:"label"`
+	Permission string  `json:"permission"`
+	// ExpiresAt is an RFC 3339 time; links without it never expire
+	ExpiresAt *string `json:"expires_at"`
+}
+
+// SharedGuideResponse is a guide opened through a share link
+type SharedGuideResponse struct {
+	GuideResponse
+	Share SharedLink `json:"share"`
+}
+
+// SharedLink tells a link holder what the link allows
+type SharedLink struct {
+	Permission string     `json:"permission"`
+	CanComment bool       `json:"can_comment"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// GetGuideShares returns a guide's share links with their view counts (owner only)
+func GetGuideShares(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findOwnGuide(c, userID)
+	if err != nil {
+		return err
+	}
+
+	var shares []models.GuideShare
+	if err := database.DB.Where("guide_id = ?", guide.ID).Order("created_at DESC").Find(&shares).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch share links",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"shares": shares,
+		"count":  len(shares),
+	})
+}
+
+// CreateGuideShare creates a secret link to a guide (owner only)
+func CreateGuideShare(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findOwnGuide(c, userID)
+	if err != nil {
+		return err
+	}
+
+	var req GuideShareRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	share := models.GuideShare{GuideID: guide.ID, Permission: models.SharePermissionView}
+	switch permission := strings.ToLower(strings.TrimSpace(req.Permission)); permission {
+	case "", models.SharePermissionView:
+	case models.SharePermissionComment:
+		share.Permission = permission
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Permission must be view or comment",
+		})
+	}
+	if req.Label != nil {
+		label := strings.TrimSpace(*req.Label)
+		if len([]rune(label)) > models.MaxShareLabel {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Label is too long",
+			})
+		}
+		if label != "" {
+			share.Label = &label
+		}
+	}
+	if req.ExpiresAt != nil && *req.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, *req.ExpiresAt)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Expiry must be an RFC 3339 time",
+			})
+		}
+		if !expiresAt.After(time.Now()) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Expiry must be in the future",
+			})
+		}
+		share.ExpiresAt = &expiresAt
+	}
+
+	share.Token, err = newSecretToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create share link",
+		})
+	}
+	if err := database.DB.Create(&share).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create share link",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(share)
+}
+
+// RevokeGuideShare stops a share link from working. The link is kept so its
+// view count stays visible (owner only).
+func RevokeGuideShare(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	guide, err := findOwnGuide(c, userID)
+	if err != nil {
+		return err
+	}
+	shareID, err := uuid.Parse(c.Params("shareId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid share ID",
+		})
+	}
+
+	var share models.GuideShare
+	if err := database.DB.Where("guide_id = ?", guide.ID).First(&share, shareID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Share link not found",
+		})
+	}
+	if share.RevokedAt == nil {
+		now := time.Now()
+		share.RevokedAt = &now
+		if err := database.DB.Model(&share).Update("revoked_at", now).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to revoke share link",
+			})
+		}
+	}
+
+	return c.JSON(share)
+}
+
+// GetSharedGuide opens a guide through a share link, without logging in, and
+// counts the view. Stops are shown as the link holder is allowed to see them.
+func GetSharedGuide(c *fiber.Ctx) error {
+	guide, share, err := findSharedGuide(c)
+	if err != nil {
+		return err
+	}
+
+	stops, err := guideStops(guide.ID, optionalUserID(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch guide",
+		})
+	}
+
+	database.DB.Model(share).UpdateColumns(map[string]interface{}{
+		"view_count":     gorm.Expr("view_count + 1"),
+		"last_viewed_at": time.Now(),
+	})
+
+	return c.JSON(SharedGuideResponse{
+		GuideResponse: GuideResponse{Guide: *guide, Stops: stops},
+		Share: SharedLink{
+			Permission: share.Permission,
+			CanComment: share.CanComment(),
+			ExpiresAt:  share.ExpiresAt,
+		},
+	})
+}
+
+// findSharedGuide loads the guide behind the share token in the route.
+// Revoked and expired links are gone rather than not found.
+func findSharedGuide(c *fiber.Ctx) (*models.Guide, *models.GuideShare, error) {
+	var share models.GuideShare
+	if err := database.DB.Where("token = ?", c.Params("token")).First(&share).Error; err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusNotFound, "Share link not found")
+	}
+	if !share.Active(time.Now()) {
+		return nil, nil, fiber.NewError(fiber.StatusGone, "This share link has expired or been revoked")
+	}
+
+	var guide models.Guide
+	if err := database.DB.Preload("User").First(&guide, share.GuideID).Error; err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusNotFound, "Share link not found")
+	}
+	return &guide, &share, nil
+}
+
+// newSecretToken returns an unguessable URL-safe token
+func newSecretToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
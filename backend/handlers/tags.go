@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MergeTagsRequest represents tag merge payload
+type MergeTagsRequest struct {
+	Sources []string `json:"sources"`
+	Target  string   `json:"target"`
+}
+
+// GetTags returns tags with usage counts, optionally scoped to a city and category
+func GetTags(c *fiber.Ctx) error {
+	cityName := c.Query("city")
+	category := c.Query("category")
+	prefix := models.NormalizeTag(c.Query("q"))
+
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	query := database.DB.Table("locations, unnest(locations.tags) AS tag").
+		Select("tag, COUNT(*) AS count").
+		Where("locations.visibility = ? AND locations.deleted_at IS NULL", models.VisibilityPublic)
+
+	if cityName != "" {
+		city, err := database.FindCityByName(cityName)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch tags",
+			})
+		}
+		if city != nil {
+			query = query.Where("locations.city_id = ?", city.ID)
+		} else {
+			query = query.Where("locations.city ILIKE ?", "%"+cityName+"%")
+		}
+	}
+	if category != "" {
+		query = query.Where("locations.category = ?", category)
+	}
+	if prefix != "" {
+		query = query.Where("tag LIKE ?", prefix+"%")
+	}
+
+	var tags []database.TagCount
+	err := query.Group("tag").Order("count DESC, tag ASC").Limit(limit).Scan(&tags).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch tags",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"tags":  tags,
+		"count": len(tags),
+	})
+}
+
+// GetTagAliases returns all tag synonyms (admin only)
+func GetTagAliases(c *fiber.Ctx) error {
+	var aliases []models.TagAlias
+	if err := database.DB.Order("tag ASC, alias ASC").Find(&aliases).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch tag aliases",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"aliases": aliases,
+		"count":   len(aliases),
+	})
+}
+
+// MergeTags folds one or more tags into a target tag and keeps the sources
+// as synonyms for future writes (admin only)
+func MergeTags(c *fiber.Ctx) error {
+	var req MergeTagsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	target := models.NormalizeTag(req.Target)
+	sources := models.NormalizeTags(req.Sources)
+	if target == "" || len(sources) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Sources and target are required",
+		})
+	}
+
+	// A target that is itself an alias would create a chain
+	var targetAlias int64
+	database.DB.Model(&models.TagAlias{}).Where("alias = ?", target).Count(&targetAlias)
+	if targetAlias > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Target tag is itself an alias",
+		})
+	}
+
+	updated, err := database.MergeTags(sources, target)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to merge tags",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":           "Tags merged successfully",
+		"target":            target,
+		"sources":           sources,
+		"locations_updated": updated,
+	})
+}
+
+// DeleteTagAlias removes a tag synonym without touching existing locations (admin only)
+func DeleteTagAlias(c *fiber.Ctx) error {
+	alias := models.NormalizeTag(c.Params("alias"))
+
+	result := database.DB.Where("alias = ?", alias).Delete(&models.TagAlias{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete tag alias",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tag alias not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Tag alias deleted successfully",
+	})
+}
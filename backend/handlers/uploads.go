@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"myarea-backend/database"
+	"myarea-backend/media"
+	"myarea-backend/models"
+	"myarea-backend/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// UploadLocationImage uploads a photo and makes it the location's cover,
+// which also sets image_url (owner only)
+func UploadLocationImage(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	locationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid location ID",
+		})
+	}
+
+	var location models.Location
+	if err := database.DB.First(&location, locationID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Location not found",
+		})
+	}
+
+	if location.UserID != userID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You can only upload photos to your own locations",
+		})
+	}
+
+	data, err := readUploadedImage(c, "image")
+	if err != nil {
+		return respondUploadError(c, err)
+	}
+
+	photo, err := addLocationPhoto(c.UserContext(), &location, userID, data, nil, true)
+	if err != nil {
+		return respondUploadError(c, err)
+	}
+
+	database.DB.Preload("User").First(&location, location.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"location": location,
+		"photo":    photo,
+	})
+}
+
+// UploadAvatar replaces the current user's profile picture
+func UploadAvatar(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	data, err := readUploadedImage(c, "avatar")
+	if err != nil {
+		return respondUploadError(c, err)
+	}
+
+	image, err := storeImage(c.UserContext(), userID, models.ImageKindAvatar, user.ID, data, media.AvatarVariants)
+	if err != nil {
+		return respondUploadError(c, err)
+	}
+
+	previous := findImages(models.ImageKindAvatar, user.ID, image.ID)
+
+	avatarURL := image.URL("medium")
+	user.AvatarURL = &avatarURL
+	if err := database.DB.Save(&user).Error; err != nil {
+		deleteImage(c.UserContext(), image)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update profile",
+		})
+	}
+
+	for i := range previous {
+		deleteImage(c.UserContext(), &previous[i])
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"user":  user,
+		"image": image,
+	})
+}
+
+// errUploadMissing is returned when the multipart field is absent
+var errUploadMissing = errors.New("an image file is required")
+
+// readUploadedImage reads a multipart file field, enforcing the size limit
+func readUploadedImage(c *fiber.Ctx, field string) ([]byte, error) {
+	file, err := c.FormFile(field)
+	if err != nil {
+		return nil, errUploadMissing
+	}
+	if file.Size > media.MaxUploadBytes {
+		return nil, media.ErrTooLarge
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, media.MaxUploadBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > media.MaxUploadBytes {
+		return nil, media.ErrTooLarge
+	}
+	return data, nil
+}
+
+// respondUploadError maps upload errors to responses
+func respondUploadError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errUploadMissing):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "An image file is required",
+		})
+	case errors.Is(err, media.ErrTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("Images must be under %d MB", media.MaxUploadBytes>>20),
+		})
+	case errors.Is(err, media.ErrUnsupportedType):
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": "Images must be JPEG, PNG or GIF",
+		})
+	}
+	log.Printf("Image upload failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to upload image",
+	})
+}
+
+// storeImage processes an upload, writes every variant to the blob store and
+// records the image
+func storeImage(ctx context.Context, ownerID uuid.UUID, kind string, subjectID uuid.UUID, data []byte, variants []media.Variant) (*models.Image, error) {
+	processed, err := media.Process(data, variants)
+	if err != nil {
+		return nil, err
+	}
+
+	image := models.Image{
+		ID:        uuid.New(),
+		OwnerID:   ownerID,
+		Kind:      kind,
+		SubjectID: subjectID,
+		Width:     processed.Width,
+		Height:    processed.Height,
+		Variants:  models.ImageVariants{},
+	}
+
+	for _, out := range processed.Variants {
+		key := fmt.Sprintf("%ss/%s/%s/%s.%s", kind, subjectID, image.ID, out.Name, out.Extension)
+		url, err := storage.Default.Put(ctx, key, out.ContentType, bytes.NewReader(out.Data), int64(len(out.Data)))
+		if err != nil {
+			deleteImageBlobs(ctx, &image)
+			return nil, err
+		}
+		image.Variants[out.Name] = models.ImageVariant{
+			Key:         key,
+			URL:         url,
+			Width:       out.Width,
+			Height:      out.Height,
+			ContentType: out.ContentType,
+			Size:        len(out.Data),
+		}
+	}
+
+	if err := database.DB.Create(&image).Error; err != nil {
+		deleteImageBlobs(ctx, &image)
+		return nil, err
+	}
+	return &image, nil
+}
+
+// findImages returns the images stored for a subject, except the one to keep
+func findImages(kind string, subjectID, keep uuid.UUID) []models.Image {
+	var images []models.Image
+	database.DB.Where("kind = ? AND subject_id = ? AND id <> ?", kind, subjectID, keep).Find(&images)
+	return images
+}
+
+// deleteImage removes an image's files and record
+func deleteImage(ctx context.Context, image *models.Image) {
+	deleteImageBlobs(ctx, image)
+	if err := database.DB.Delete(image).Error; err != nil {
+		log.Printf("Failed to delete image %s: %v", image.ID, err)
+	}
+}
+
+func deleteImageBlobs(ctx context.Context, image *models.Image) {
+	for _, v := range image.Variants {
+		if err := storage.Default.Delete(ctx, v.Key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Failed to delete %s: %v", v.Key, err)
+		}
+	}
+}
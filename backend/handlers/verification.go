@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"myarea-backend/database"
+	"myarea-backend/mail"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// verificationResendInterval is how long to wait before sending another link
+const verificationResendInterval = time.Minute
+
+// VerifyEmailRequest represents email verification payload
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// SendEmailVerification emails the current user a link that proves they own
+// their address
+func SendEmailVerification(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if user.EmailVerifiedAt != nil {
+		return c.JSON(fiber.Map{
+			"message": "Your email address is already verified",
+		})
+	}
+
+	var recent int64
+	database.DB.Model(&models.EmailVerification{}).
+		Where("user_id = ? AND created_at > ?", user.ID, time.Now().Add(-verificationResendInterval)).
+		Count(&recent)
+	if recent > 0 {
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error": "A verification email was just sent. Try again in a minute",
+		})
+	}
+
+	if err := sendEmailVerification(c.UserContext(), &user); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to send verification email",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Verification email sent to " + user.Email,
+	})
+}
+
+// VerifyEmail marks the account behind a verification token as verified.
+// It doesn't need a login, since the link is usually opened from a mail client.
+func VerifyEmail(c *fiber.Ctx) error {
+	var req VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Token) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A verification token is required",
+		})
+	}
+
+	var verification models.EmailVerification
+	err := database.DB.Where("token = ? AND expires_at > ?", strings.TrimSpace(req.Token), time.Now()).First(&verification).Error
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "This verification link is invalid or has expired",
+		})
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, verification.UserID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if !strings.EqualFold(user.Email, verification.Email) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "This link was sent to an address you no longer use",
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if user.EmailVerifiedAt == nil {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+			if err := tx.Model(&user).Update("email_verified_at", now).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.EmailVerification{}).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to verify email",
+		})
+	}
+
+	return c.JSON(user)
+}
+
+// sendEmailVerification stores a new verification token for the user's
+// current address and mails the link
+func sendEmailVerification(ctx context.Context, user *models.User) error {
+	token, err := newSecretToken()
+	if err != nil {
+		return err
+	}
+	verification := models.EmailVerification{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Token:     token,
+		ExpiresAt: time.Now().Add(models.EmailVerificationTTL),
+	}
+	if err := database.DB.Create(&verification).Error; err != nil {
+		return err
+	}
+
+	err = mail.Default.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your MyArea email address",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen this link to confirm your email address:\n\n%s\n\nThe link expires in %d hours. If you didn't sign up for MyArea, you can ignore this email.\n",
+			user.DisplayName, verificationURL(token), int(models.EmailVerificationTTL.Hours())),
+	})
+	if err != nil {
+		log.Printf("Failed to send verification email to user %s: %v", user.ID, err)
+		return errors.New("failed to send verification email")
+	}
+	return nil
+}
+
+// verificationURL is the frontend page that submits token to VerifyEmail.
+// APP_URL defaults to the CORS origin.
+func verificationURL(token string) string {
+	base := os.Getenv("APP_URL")
+	if base == "" {
+		base = os.Getenv("CORS_ORIGIN")
+	}
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return strings.TrimRight(base, "/") + "/verify-email?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidEmail(t *testing.T) {
+	valid := []string{"ana@example.com", "first.last+tag@sub.example.org"}
+	invalid := []string{"", "ana", "@example.com", "ana@", "ana @example.com", "ana@example.com\r\nBcc: x", "<ana@example.com>", "a@b,c@d"}
+	for _, email := range valid {
+		if !validEmail(email) {
+			t.Errorf("validEmail(%q) = false", email)
+		}
+	}
+	for _, email := range invalid {
+		if validEmail(email) {
+			t.Errorf("validEmail(%q) = true", email)
+		}
+	}
+}
+
+func TestVerificationURL(t *testing.T) {
+	t.Setenv("APP_URL", "")
+	t.Setenv("CORS_ORIGIN", "")
+	if got := verificationURL("abc"); got != "http://localhost:3000/verify-email?token=abc" {
+		t.Errorf("default verificationURL = %q", got)
+	}
+
+	t.Setenv("CORS_ORIGIN", "https://myarea.example")
+	if got := verificationURL("abc"); got != "https://myarea.example/verify-email?token=abc" {
+		t.Errorf("verificationURL from CORS origin = %q", got)
+	}
+
+	t.Setenv("APP_URL", "https://app.myarea.example/")
+	got := verificationURL("a+b/c")
+	if !strings.HasPrefix(got, "https://app.myarea.example/verify-email?token=") || strings.Contains(got, "+") {
+		t.Errorf("verificationURL = %q, want APP_URL with an escaped token", got)
+	}
+}
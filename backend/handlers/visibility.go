@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// optionalUserID returns the caller's ID when OptionalAuth found a valid token
+func optionalUserID(c *fiber.Ctx) *uuid.UUID {
+	if userID, ok := c.Locals("user_id").(uuid.UUID); ok {
+		return &userID
+	}
+	return nil
+}
+
+// visibleLocations restricts a location query to what the viewer may see in
+// listings. Unlisted locations are left out of listings for everyone but the owner.
+func visibleLocations(query *gorm.DB, viewerID *uuid.UUID) *gorm.DB {
+	return scopeLocationVisibility(query, viewerID, []string{models.VisibilityPublic})
+}
+
+// viewableLocations restricts a location query to what the viewer may open
+// directly by ID, which includes unlisted locations
+func viewableLocations(query *gorm.DB, viewerID *uuid.UUID) *gorm.DB {
+	return scopeLocationVisibility(query, viewerID, []string{models.VisibilityPublic, models.VisibilityUnlisted})
+}
+
+func scopeLocationVisibility(query *gorm.DB, viewerID *uuid.UUID, open []string) *gorm.DB {
+	if viewerID == nil {
+		return query.Where("locations.visibility IN ?", open)
+	}
+	return query.Where(
+		"(locations.visibility IN ? OR locations.user_id = ? OR (locations.visibility = ? AND locations.user_id IN (SELECT user_id FROM friendships WHERE friend_id = ?)))",
+		open, *viewerID, models.VisibilityFriends, *viewerID,
+	)
+}
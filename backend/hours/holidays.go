@@ -0,0 +1,99 @@
+package hours
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// SetupHolidays chooses the calendar PH rules are matched against.
+// PUBLIC_HOLIDAYS is "us" (default) for US federal holidays or "none".
+func SetupHolidays() {
+	switch strings.ToLower(os.Getenv("PUBLIC_HOLIDAYS")) {
+	case "none":
+		IsPublicHoliday = nil
+		log.Println("⏭️  No public holiday calendar, PH rules never apply")
+	default:
+		IsPublicHoliday = USFederalHoliday
+		log.Println("✅ Using US federal holidays for PH rules")
+	}
+}
+
+type fixedHoliday struct {
+	month time.Month
+	day   int
+	since int
+}
+
+type floatingHoliday struct {
+	month   time.Month
+	weekday time.Weekday
+	// nth occurrence in the month, or -1 for the last
+	nth int
+}
+
+var usFixedHolidays = []fixedHoliday{
+	{time.January, 1, 0},   // New Year's Day
+	{time.June, 19, 2021},  // Juneteenth
+	{time.July, 4, 0},      // Independence Day
+	{time.November, 11, 0}, // Veterans Day
+	{time.December, 25, 0}, // Christmas Day
+}
+
+var usFloatingHolidays = []floatingHoliday{
+	{time.January, time.Monday, 3},    // Martin Luther King Jr. Day
+	{time.February, time.Monday, 3},   // Washington's Birthday
+	{time.May, time.Monday, -1},       // Memorial Day
+	{time.September, time.Monday, 1},  // Labor Day
+	{time.October, time.Monday, 2},    // Columbus Day
+	{time.November, time.Thursday, 4}, // Thanksgiving Day
+}
+
+// USFederalHoliday reports whether t falls on a US federal holiday, or on the
+// Friday or Monday a weekend holiday is observed on
+func USFederalHoliday(t time.Time) bool {
+	year, month, day := t.Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	// Next year's New Year's Day can be observed on December 31st
+	for _, y := range []int{year, year + 1} {
+		for _, h := range usFixedHolidays {
+			if y < h.since {
+				continue
+			}
+			holiday := time.Date(y, h.month, h.day, 0, 0, 0, 0, time.UTC)
+			if holiday.Equal(date) || observed(holiday).Equal(date) {
+				return true
+			}
+		}
+	}
+	for _, h := range usFloatingHolidays {
+		if h.month == month && nthWeekday(year, h.month, h.weekday, h.nth).Equal(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// observed moves a Saturday holiday to Friday and a Sunday one to Monday
+func observed(holiday time.Time) time.Time {
+	switch holiday.Weekday() {
+	case time.Saturday:
+		return holiday.AddDate(0, 0, -1)
+	case time.Sunday:
+		return holiday.AddDate(0, 0, 1)
+	}
+	return holiday
+}
+
+// nthWeekday returns the nth given weekday of a month, or the last when n is -1
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	if n < 0 {
+		last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+		return last.AddDate(0, 0, -((int(last.Weekday()) - int(weekday) + 7) % 7))
+	}
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
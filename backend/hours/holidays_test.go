@@ -0,0 +1,65 @@
+package hours
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUSFederalHoliday(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 12, 0, 0, 0, time.UTC)
+	}
+	holidays := []time.Time{
+		day(2024, time.January, 1),
+		day(2024, time.January, 15),
+		day(2024, time.February, 19),
+		day(2024, time.May, 27),
+		day(2024, time.June, 19),
+		day(2024, time.July, 4),
+		day(2024, time.September, 2),
+		day(2024, time.October, 14),
+		day(2024, time.November, 11),
+		day(2024, time.November, 28),
+		day(2024, time.December, 25),
+		// Observed on the nearest weekday
+		day(2026, time.July, 3),
+		day(2021, time.December, 31),
+		day(2022, time.December, 26),
+	}
+	for _, d := range holidays {
+		if !USFederalHoliday(d) {
+			t.Errorf("%s should be a holiday", d.Format("Mon 2006-01-02"))
+		}
+	}
+
+	workdays := []time.Time{
+		day(2024, time.January, 2),
+		day(2024, time.May, 20),
+		day(2024, time.November, 21),
+		day(2024, time.December, 24),
+		day(2020, time.June, 19),
+		day(2024, time.July, 5),
+	}
+	for _, d := range workdays {
+		if USFederalHoliday(d) {
+			t.Errorf("%s should not be a holiday", d.Format("Mon 2006-01-02"))
+		}
+	}
+}
+
+func TestSetupHolidays(t *testing.T) {
+	defer func() { IsPublicHoliday = nil }()
+
+	t.Setenv("PUBLIC_HOLIDAYS", "")
+	SetupHolidays()
+	s, _ := Parse("Mo-Su 10:00-18:00; PH off")
+	if s.IsOpen(time.Date(2024, time.December, 25, 12, 0, 0, 0, time.UTC)) {
+		t.Error("open on Christmas Day with the default calendar")
+	}
+
+	t.Setenv("PUBLIC_HOLIDAYS", "none")
+	SetupHolidays()
+	if IsPublicHoliday != nil {
+		t.Error("PUBLIC_HOLIDAYS=none left a calendar set")
+	}
+}
@@ -0,0 +1,326 @@
+// Package hours parses, evaluates and formats opening hours written in the
+// OpenStreetMap opening_hours syntax, e.g. "Mo-Fr 08:00-18:00; Sa 10:00-14:00; Dec 25 off".
+//
+// The supported subset covers weekly schedules, split and overnight time
+// spans, month and date exceptions (holidays, seasonal closures), "off" and
+// "24/7". Later rules override earlier ones for the days they match, as in OSM.
+package hours
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// minutesPerDay is the length of a day in minutes
+const minutesPerDay = 24 * 60
+
+// Schedule is a parsed set of opening hours rules
+type Schedule struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule applies to the days matched by its date and weekday selectors. An empty
+// selector matches every day. A rule with no intervals that is not Closed
+// means open all day.
+type Rule struct {
+	Dates          []DateRange `json:"dates,omitempty"`
+	Weekdays       []Weekday   `json:"weekdays,omitempty"`
+	PublicHolidays bool        `json:"public_holidays,omitempty"`
+	Intervals      []Interval  `json:"intervals,omitempty"`
+	Closed         bool        `json:"closed,omitempty"`
+}
+
+// Interval is an opening span in minutes since midnight. End may exceed 24:00
+// for spans that run past midnight.
+type Interval struct {
+	Start int
+	End   int
+}
+
+// Weekday is a day of the week that marshals as its OSM abbreviation
+type Weekday time.Weekday
+
+// DateRange matches calendar days from FromMonth/FromDay to ToMonth/ToDay,
+// wrapping over the new year. A zero day means the whole month.
+type DateRange struct {
+	FromMonth time.Month `json:"from_month"`
+	FromDay   int        `json:"from_day,omitempty"`
+	ToMonth   time.Month `json:"to_month"`
+	ToDay     int        `json:"to_day,omitempty"`
+}
+
+var weekdayNames = []string{"Su", "Mo", "Tu", "We", "Th", "Fr", "Sa"}
+
+var monthNames = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+
+// IsPublicHoliday reports whether t falls on a public holiday. SetupHolidays
+// sets it; while it is nil, holiday-only rules never apply.
+var IsPublicHoliday func(t time.Time) bool
+
+// IsOpen reports whether the schedule is open at t, evaluated in t's location
+func (s *Schedule) IsOpen(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+
+	for _, iv := range s.intervalsOn(t) {
+		if minute >= iv.Start && minute < iv.End {
+			return true
+		}
+	}
+	// Spans from the previous day that run past midnight
+	for _, iv := range s.intervalsOn(t.AddDate(0, 0, -1)) {
+		if iv.End > minutesPerDay && minute < iv.End-minutesPerDay {
+			return true
+		}
+	}
+	return false
+}
+
+// intervalsOn returns the opening intervals that apply on the day of t
+func (s *Schedule) intervalsOn(t time.Time) []Interval {
+	var result []Interval
+	for _, rule := range s.Rules {
+		if !rule.matches(t) {
+			continue
+		}
+		// The last matching rule wins
+		switch {
+		case rule.Closed:
+			result = nil
+		case len(rule.Intervals) == 0:
+			result = []Interval{{Start: 0, End: minutesPerDay}}
+		default:
+			result = rule.Intervals
+		}
+	}
+	return result
+}
+
+func (r Rule) matches(t time.Time) bool {
+	// PH is one more day selector alongside the weekdays: "Sa,PH" matches
+	// Saturdays and holidays, a bare "PH" matches holidays only
+	if len(r.Weekdays) > 0 || r.PublicHolidays {
+		found := r.PublicHolidays && IsPublicHoliday != nil && IsPublicHoliday(t)
+		for _, wd := range r.Weekdays {
+			if time.Weekday(wd) == t.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.Dates) > 0 {
+		found := false
+		for _, dr := range r.Dates {
+			if dr.contains(t.Month(), t.Day()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (d DateRange) contains(month time.Month, day int) bool {
+	toDay := d.ToDay
+	if toDay == 0 {
+		toDay = 31
+	}
+	v := int(month)*100 + day
+	from := int(d.FromMonth)*100 + d.FromDay
+	to := int(d.ToMonth)*100 + toDay
+	if from <= to {
+		return v >= from && v <= to
+	}
+	// Wraps over the new year, e.g. Dec 20-Jan 05
+	return v >= from || v <= to
+}
+
+// String formats the schedule in canonical OSM syntax
+func (s *Schedule) String() string {
+	if s.is247() {
+		return "24/7"
+	}
+	parts := make([]string, 0, len(s.Rules))
+	for _, rule := range s.Rules {
+		parts = append(parts, rule.String())
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (s *Schedule) is247() bool {
+	if len(s.Rules) != 1 {
+		return false
+	}
+	r := s.Rules[0]
+	return len(r.Dates) == 0 && len(r.Weekdays) == 0 && !r.PublicHolidays && !r.Closed &&
+		(len(r.Intervals) == 0 || (len(r.Intervals) == 1 && r.Intervals[0] == Interval{0, minutesPerDay}))
+}
+
+// String formats a single rule
+func (r Rule) String() string {
+	var parts []string
+	if len(r.Dates) > 0 {
+		dates := make([]string, 0, len(r.Dates))
+		for _, d := range r.Dates {
+			dates = append(dates, d.String())
+		}
+		parts = append(parts, strings.Join(dates, ","))
+	}
+	if days := formatWeekdays(r.Weekdays, r.PublicHolidays); days != "" {
+		parts = append(parts, days)
+	}
+	switch {
+	case r.Closed:
+		parts = append(parts, "off")
+	case len(r.Intervals) > 0:
+		spans := make([]string, 0, len(r.Intervals))
+		for _, iv := range r.Intervals {
+			spans = append(spans, iv.String())
+		}
+		parts = append(parts, strings.Join(spans, ","))
+	default:
+		parts = append(parts, "00:00-24:00")
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatWeekdays compresses weekdays into ranges, Monday first: "Mo-Fr,Su"
+func formatWeekdays(days []Weekday, holidays bool) string {
+	var present [7]bool
+	for _, d := range days {
+		present[d] = true
+	}
+	var parts []string
+	// Walk Monday..Sunday
+	order := []int{1, 2, 3, 4, 5, 6, 0}
+	for i := 0; i < len(order); {
+		if !present[order[i]] {
+			i++
+			continue
+		}
+		j := i
+		for j+1 < len(order) && present[order[j+1]] {
+			j++
+		}
+		switch {
+		case j == i:
+			parts = append(parts, weekdayNames[order[i]])
+		case j == i+1:
+			parts = append(parts, weekdayNames[order[i]], weekdayNames[order[j]])
+		default:
+			parts = append(parts, weekdayNames[order[i]]+"-"+weekdayNames[order[j]])
+		}
+		i = j + 1
+	}
+	if holidays {
+		parts = append(parts, "PH")
+	}
+	return strings.Join(parts, ",")
+}
+
+// String formats the interval as HH:MM-HH:MM
+func (iv Interval) String() string {
+	return formatMinutes(iv.Start) + "-" + formatMinutes(iv.closing())
+}
+
+// closing is the end time on the clock; spans past midnight wrap to the next day
+func (iv Interval) closing() int {
+	if iv.End > minutesPerDay {
+		return iv.End - minutesPerDay
+	}
+	return iv.End
+}
+
+func formatMinutes(m int) string {
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+// String formats the date range, e.g. "Dec 24-26" or "Jan-Mar"
+func (d DateRange) String() string {
+	from := monthNames[d.FromMonth-1]
+	if d.FromDay > 0 {
+		from += fmt.Sprintf(" %02d", d.FromDay)
+	}
+	if d.FromMonth == d.ToMonth && d.FromDay == d.ToDay {
+		return from
+	}
+	if d.FromMonth == d.ToMonth && d.FromDay > 0 && d.ToDay > 0 {
+		return from + fmt.Sprintf("-%02d", d.ToDay)
+	}
+	to := monthNames[d.ToMonth-1]
+	if d.ToDay > 0 {
+		to += fmt.Sprintf(" %02d", d.ToDay)
+	}
+	return from + "-" + to
+}
+
+// MarshalJSON encodes the weekday as its OSM abbreviation
+func (w Weekday) MarshalJSON() ([]byte, error) {
+	return json.Marshal(weekdayNames[w])
+}
+
+// UnmarshalJSON decodes an OSM weekday abbreviation
+func (w *Weekday) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	d, ok := parseWeekday(name)
+	if !ok {
+		return fmt.Errorf("invalid weekday %q", name)
+	}
+	*w = d
+	return nil
+}
+
+// MarshalJSON encodes the interval as {"open": "HH:MM", "close": "HH:MM"}
+func (iv Interval) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"open":  formatMinutes(iv.Start),
+		"close": formatMinutes(iv.closing()),
+	})
+}
+
+// UnmarshalJSON decodes {"open": "HH:MM", "close": "HH:MM"}
+func (iv *Interval) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Open  string `json:"open"`
+		Close string `json:"close"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	parsed, err := parseInterval(raw.Open + "-" + raw.Close)
+	if err != nil {
+		return err
+	}
+	*iv = parsed
+	return nil
+}
+
+// OpenThroughout reports whether the schedule is open for the whole of [from, to)
+func (s *Schedule) OpenThroughout(from, to time.Time) bool {
+	for t := from; t.Before(to); t = t.Add(time.Minute) {
+		if !s.IsOpen(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// OpenDuring reports whether the schedule is open at any point in [from, to)
+func (s *Schedule) OpenDuring(from, to time.Time) bool {
+	for t := from; t.Before(to); t = t.Add(time.Minute) {
+		if s.IsOpen(t) {
+			return true
+		}
+	}
+	return false
+}
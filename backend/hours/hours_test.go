@@ -0,0 +1,144 @@
+package hours
+
+import (
+	"testing"
+	"time"
+)
+
+// 2024-06-03 is a Monday
+func at(day, hour, minute int) time.Time {
+	return time.Date(2024, time.June, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"24/7", "24/7"},
+		{"Mo-Fr 08:00-18:00", "Mo-Fr 08:00-18:00"},
+		{"Mo-Fr 09:00-12:00, 13:00-17:00", "Mo-Fr 09:00-12:00,13:00-17:00"},
+		{"mo,tu,we 10:00-14:00", "Mo-We 10:00-14:00"},
+		{"Fr-Mo 22:00-02:00", "Mo,Fr-Su 22:00-02:00"},
+		{"Sa,PH 10:00-14:00", "Sa,PH 10:00-14:00"},
+		{"PH off", "PH off"},
+		{"Mo-Sa 10:00-18:00; Dec 25 off", "Mo-Sa 10:00-18:00; Dec 25 off"},
+		{"Dec 24-26 off", "Dec 24-26 off"},
+		{"Dec 20-Jan 05 off", "Dec 20-Jan 05 off"},
+		{"Jan-Mar Sa 10:00-12:00", "Jan-Mar Sa 10:00-12:00"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.input, err)
+			continue
+		}
+		if got := s.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		" ; ",
+		"Xx 10:00-12:00",
+		"Mo 25:00-26:00",
+		"Mo 10:00",
+		"Mo 10:00-12:00 off",
+		"Foo 10:00-12:00",
+		"Dec 32 off",
+	} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestIsOpen(t *testing.T) {
+	tests := []struct {
+		hours string
+		at    time.Time
+		want  bool
+	}{
+		{"24/7", at(3, 3, 0), true},
+		{"Mo-Fr 08:00-18:00", at(3, 8, 0), true},
+		{"Mo-Fr 08:00-18:00", at(3, 18, 0), false},
+		{"Mo-Fr 08:00-18:00", at(8, 12, 0), false},
+		{"Mo-Fr 09:00-12:00,13:00-17:00", at(4, 12, 30), false},
+		{"Mo-Fr 09:00-12:00,13:00-17:00", at(4, 13, 30), true},
+		// Overnight span carries into the next morning
+		{"Fr 22:00-02:00", at(7, 23, 0), true},
+		{"Fr 22:00-02:00", at(8, 1, 59), true},
+		{"Fr 22:00-02:00", at(8, 2, 0), false},
+		{"Fr 22:00-02:00", at(7, 1, 0), false},
+		// Later rules override earlier ones
+		{"Mo-Su 10:00-18:00; Jun 05 off", at(5, 12, 0), false},
+		{"Mo-Su 10:00-18:00; Jun 05 off", at(6, 12, 0), true},
+		{"Mo-Su 10:00-18:00; Sa 12:00-14:00", at(8, 11, 0), false},
+		{"Mo-Su 10:00-18:00; Sa 12:00-14:00", at(8, 13, 0), true},
+		// PH alongside a weekday still matches that weekday
+		{"Sa,PH 10:00-14:00", at(8, 11, 0), true},
+		{"Sa,PH 10:00-14:00", at(7, 11, 0), false},
+		// Without a holiday calendar a bare PH rule never applies
+		{"PH 10:00-14:00", at(8, 11, 0), false},
+		{"Mo-Su 10:00-18:00; PH off", at(3, 11, 0), true},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.hours)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.hours, err)
+		}
+		if got := s.IsOpen(tt.at); got != tt.want {
+			t.Errorf("%q IsOpen(%s) = %v, want %v", tt.hours, tt.at.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestIsOpenPublicHolidays(t *testing.T) {
+	// Treat Wednesday June 5th as a holiday
+	IsPublicHoliday = func(t time.Time) bool {
+		return t.Month() == time.June && t.Day() == 5
+	}
+	defer func() { IsPublicHoliday = nil }()
+
+	tests := []struct {
+		hours string
+		at    time.Time
+		want  bool
+	}{
+		{"Sa,PH 10:00-14:00", at(5, 11, 0), true},
+		{"Sa,PH 10:00-14:00", at(8, 11, 0), true},
+		{"Sa,PH 10:00-14:00", at(4, 11, 0), false},
+		{"PH 10:00-14:00", at(5, 11, 0), true},
+		{"PH 10:00-14:00", at(8, 11, 0), false},
+		{"Mo-Su 10:00-18:00; PH off", at(5, 11, 0), false},
+		{"Mo-Su 10:00-18:00; PH off", at(6, 11, 0), true},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.hours)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.hours, err)
+		}
+		if got := s.IsOpen(tt.at); got != tt.want {
+			t.Errorf("%q IsOpen(%s) = %v, want %v", tt.hours, tt.at.Format("Mon Jan 2 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestOpenThroughout(t *testing.T) {
+	s, err := Parse("Mo-Fr 09:00-17:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.OpenThroughout(at(3, 9, 0), at(3, 17, 0)) {
+		t.Error("expected open for the whole working day")
+	}
+	if s.OpenThroughout(at(3, 16, 0), at(3, 18, 0)) {
+		t.Error("expected closed after 17:00")
+	}
+	if !s.OpenDuring(at(3, 16, 0), at(3, 18, 0)) {
+		t.Error("expected open during part of the window")
+	}
+}
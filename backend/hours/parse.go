@@ -0,0 +1,293 @@
+package hours
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parse reads an OSM opening_hours string
+func Parse(input string) (*Schedule, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, fmt.Errorf("opening hours are empty")
+	}
+
+	schedule := &Schedule{}
+	for _, raw := range strings.Split(input, ";") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		rule, err := parseRule(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid opening hours rule %q: %w", raw, err)
+		}
+		schedule.Rules = append(schedule.Rules, rule)
+	}
+	if len(schedule.Rules) == 0 {
+		return nil, fmt.Errorf("opening hours are empty")
+	}
+	return schedule, nil
+}
+
+// Validate checks a structured schedule for out-of-range values
+func (s *Schedule) Validate() error {
+	if len(s.Rules) == 0 {
+		return fmt.Errorf("opening hours need at least one rule")
+	}
+	for _, rule := range s.Rules {
+		for _, iv := range rule.Intervals {
+			if iv.Start < 0 || iv.Start >= minutesPerDay || iv.End <= iv.Start || iv.End > 2*minutesPerDay {
+				return fmt.Errorf("invalid time span %s", iv)
+			}
+		}
+		for _, d := range rule.Dates {
+			if d.FromMonth < time.January || d.FromMonth > time.December || d.ToMonth < time.January || d.ToMonth > time.December {
+				return fmt.Errorf("invalid month in date range")
+			}
+			if d.FromDay < 0 || d.FromDay > 31 || d.ToDay < 0 || d.ToDay > 31 {
+				return fmt.Errorf("invalid day in date range")
+			}
+		}
+	}
+	return nil
+}
+
+func parseRule(raw string) (Rule, error) {
+	var rule Rule
+
+	// Allow "Mo-Fr 09:00-12:00, 13:00-17:00" as well as "09:00-12:00,13:00-17:00"
+	raw = strings.Join(strings.Fields(strings.ReplaceAll(raw, ", ", ",")), " ")
+	if raw == "24/7" {
+		return Rule{Intervals: []Interval{{Start: 0, End: minutesPerDay}}}, nil
+	}
+
+	tokens := strings.Split(raw, " ")
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		lower := strings.ToLower(token)
+
+		switch {
+		case lower == "off" || lower == "closed":
+			rule.Closed = true
+
+		case lower == "open":
+			// Open all day; nothing to record
+
+		case isMonthToken(token):
+			// A date selector may span tokens: "Dec 24-26" or "Dec 24-Jan 02"
+			selector := token
+			for i+1 < len(tokens) && (startsWithDigit(tokens[i+1]) && !strings.Contains(tokens[i+1], ":") ||
+				strings.HasSuffix(selector, "-") || strings.HasSuffix(selector, ",")) {
+				i++
+				selector += " " + tokens[i]
+			}
+			dates, err := parseDateSelector(selector)
+			if err != nil {
+				return rule, err
+			}
+			rule.Dates = append(rule.Dates, dates...)
+
+		case isWeekdayToken(token):
+			days, holidays, err := parseWeekdaySelector(token)
+			if err != nil {
+				return rule, err
+			}
+			rule.Weekdays = append(rule.Weekdays, days...)
+			rule.PublicHolidays = rule.PublicHolidays || holidays
+
+		case strings.Contains(token, ":"):
+			for _, span := range strings.Split(token, ",") {
+				if span == "" {
+					continue
+				}
+				iv, err := parseInterval(span)
+				if err != nil {
+					return rule, err
+				}
+				rule.Intervals = append(rule.Intervals, iv)
+			}
+
+		default:
+			return rule, fmt.Errorf("unrecognized %q", token)
+		}
+	}
+
+	if rule.Closed && len(rule.Intervals) > 0 {
+		return rule, fmt.Errorf("a rule cannot be both open and off")
+	}
+	return rule, nil
+}
+
+func parseInterval(span string) (Interval, error) {
+	parts := strings.Split(span, "-")
+	if len(parts) != 2 {
+		return Interval{}, fmt.Errorf("invalid time span %q", span)
+	}
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return Interval{}, err
+	}
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return Interval{}, err
+	}
+	if start >= minutesPerDay {
+		return Interval{}, fmt.Errorf("invalid time span %q", span)
+	}
+	if end <= start {
+		// Runs past midnight, e.g. 22:00-02:00
+		end += minutesPerDay
+	}
+	if end > 2*minutesPerDay {
+		return Interval{}, fmt.Errorf("invalid time span %q", span)
+	}
+	return Interval{Start: start, End: end}, nil
+}
+
+func parseClock(s string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || h < 0 || h > 48 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return h*60 + m, nil
+}
+
+func parseWeekdaySelector(selector string) ([]Weekday, bool, error) {
+	var days []Weekday
+	holidays := false
+	for _, part := range strings.Split(selector, ",") {
+		if part == "" {
+			continue
+		}
+		if strings.EqualFold(part, "PH") {
+			holidays = true
+			continue
+		}
+		bounds := strings.Split(part, "-")
+		from, ok := parseWeekday(bounds[0])
+		if !ok {
+			return nil, false, fmt.Errorf("invalid weekday %q", bounds[0])
+		}
+		if len(bounds) == 1 {
+			days = append(days, from)
+			continue
+		}
+		to, ok := parseWeekday(bounds[1])
+		if len(bounds) != 2 || !ok {
+			return nil, false, fmt.Errorf("invalid weekday range %q", part)
+		}
+		// Ranges can wrap, e.g. Fr-Mo
+		for d := from; ; d = (d + 1) % 7 {
+			days = append(days, d)
+			if d == to {
+				break
+			}
+		}
+	}
+	return days, holidays, nil
+}
+
+func parseWeekday(s string) (Weekday, bool) {
+	for i, name := range weekdayNames {
+		if strings.EqualFold(s, name) {
+			return Weekday(i), true
+		}
+	}
+	return 0, false
+}
+
+// parseDateSelector reads "Jan-Mar", "Dec 25", "Dec 24-26" or "Dec 24-Jan 02",
+// separated by commas
+func parseDateSelector(selector string) ([]DateRange, error) {
+	var ranges []DateRange
+	for _, item := range strings.Split(selector, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		bounds := strings.SplitN(item, "-", 2)
+		fromMonth, fromDay, err := parseMonthDay(bounds[0], 0)
+		if err != nil {
+			return nil, err
+		}
+		r := DateRange{FromMonth: fromMonth, FromDay: fromDay, ToMonth: fromMonth, ToDay: fromDay}
+		if len(bounds) == 2 {
+			r.ToMonth, r.ToDay, err = parseMonthDay(bounds[1], fromMonth)
+			if err != nil {
+				return nil, err
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// parseMonthDay reads "Dec", "Dec 25" or, when defaultMonth is set, "26"
+func parseMonthDay(s string, defaultMonth time.Month) (time.Month, int, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("invalid date %q", s)
+	}
+
+	month := defaultMonth
+	if m, ok := parseMonth(fields[0]); ok {
+		month = m
+		fields = fields[1:]
+	} else if defaultMonth == 0 {
+		return 0, 0, fmt.Errorf("invalid month %q", fields[0])
+	}
+
+	day := 0
+	if len(fields) > 0 {
+		d, err := strconv.Atoi(fields[0])
+		if err != nil || d < 1 || d > 31 || len(fields) > 1 {
+			return 0, 0, fmt.Errorf("invalid date %q", s)
+		}
+		day = d
+	}
+	return month, day, nil
+}
+
+func parseMonth(s string) (time.Month, bool) {
+	for i, name := range monthNames {
+		if strings.EqualFold(s, name) {
+			return time.Month(i + 1), true
+		}
+	}
+	return 0, false
+}
+
+func isMonthToken(token string) bool {
+	if len(token) < 3 {
+		return false
+	}
+	_, ok := parseMonth(token[:3])
+	return ok && (len(token) == 3 || !isLetter(token[3]))
+}
+
+func isWeekdayToken(token string) bool {
+	if len(token) < 2 {
+		return false
+	}
+	if strings.EqualFold(token[:2], "PH") {
+		return true
+	}
+	_, ok := parseWeekday(token[:2])
+	return ok && (len(token) == 2 || !isLetter(token[2]))
+}
+
+func startsWithDigit(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
+
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
@@ -0,0 +1,190 @@
+// Package ical writes iCalendar (RFC 5545) calendars of simple events: timed
+// events in UTC and all-day events, with location, geo position, description
+// and URL.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrControlCharacter is returned for content lines with control characters
+	// that escaping didn't remove, which could inject properties
+	ErrControlCharacter = errors.New("ical: control character in content line")
+	// ErrInvalidURL is returned for event URLs that aren't absolute http(s) URLs
+	ErrInvalidURL = errors.New("ical: event URL must be an http or https URL")
+)
+
+// maxLineOctets is the longest content line allowed before folding
+const maxLineOctets = 75
+
+// Calendar is a VCALENDAR with its events
+type Calendar struct {
+	ProdID string
+	Name   string
+	// RefreshInterval suggests how often subscribers should reload the calendar
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Event is a VEVENT. AllDay events use only the date of Start and End, where
+// End is exclusive; other events are written in UTC.
+type Event struct {
+	UID          string
+	Sequence     int
+	Stamp        time.Time
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	Summary      string
+	Description  string
+	Location     string
+	Latitude     float64
+	Longitude    float64
+	HasGeo       bool
+	URL          string
+	LastModified time.Time
+}
+
+// Write encodes the calendar. Nothing is written if any value would break
+// out of its content line.
+func (cal *Calendar) Write(w io.Writer) error {
+	var buf strings.Builder
+	out := bufio.NewWriter(&buf)
+	var err error
+	line := func(name, value string) {
+		if err == nil {
+			err = writeFolded(out, name+":"+value)
+		}
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", cal.ProdID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		line("X-WR-CALNAME", escapeText(cal.Name))
+	}
+	if cal.RefreshInterval > 0 {
+		line("REFRESH-INTERVAL;VALUE=DURATION", formatDuration(cal.RefreshInterval))
+		line("X-PUBLISHED-TTL", formatDuration(cal.RefreshInterval))
+	}
+
+	for _, event := range cal.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", formatUTC(event.Stamp))
+		if event.Sequence > 0 {
+			line("SEQUENCE", fmt.Sprint(event.Sequence))
+		}
+		if event.AllDay {
+			line("DTSTART;VALUE=DATE", event.Start.Format("20060102"))
+			line("DTEND;VALUE=DATE", event.End.Format("20060102"))
+		} else {
+			line("DTSTART", formatUTC(event.Start))
+			line("DTEND", formatUTC(event.End))
+		}
+		line("SUMMARY", escapeText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION", escapeText(event.Location))
+		}
+		if event.HasGeo {
+			line("GEO", fmt.Sprintf("%.6f;%.6f", event.Latitude, event.Longitude))
+		}
+		if event.URL != "" {
+			if !ValidURL(event.URL) {
+				return ErrInvalidURL
+			}
+			line("URL;VALUE=URI", event.URL)
+		}
+		if !event.LastModified.IsZero() {
+			line("LAST-MODIFIED", formatUTC(event.LastModified))
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	if err != nil {
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, buf.String())
+	return err
+}
+
+// ValidURL reports whether s is an absolute http or https URL without
+// whitespace or control characters, safe to write as a URI value
+func ValidURL(s string) bool {
+	if strings.IndexFunc(s, func(r rune) bool { return r <= ' ' || r == 0x7f }) >= 0 {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// escapeText escapes a TEXT value. Line breaks become \n and other control
+// characters but tab are dropped.
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", `\n`).Replace(s)
+	return strings.Map(func(r rune) rune {
+		if isControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// isControl reports whether r is a control character not allowed in a content
+// line; RFC 5545 only permits horizontal tab
+func isControl(r rune) bool {
+	return (r < ' ' && r != '\t') || r == 0x7f
+}
+
+// writeFolded writes a content line, folding it into 75-octet lines without
+// splitting UTF-8 sequences. Lines with control characters are refused.
+func writeFolded(w *bufio.Writer, line string) error {
+	if strings.IndexFunc(line, isControl) >= 0 {
+		return ErrControlCharacter
+	}
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts toward the limit
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+	return nil
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// formatDuration writes a duration as an RFC 5545 DURATION in whole minutes
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Minutes())
+	if minutes%60 == 0 {
+		return fmt.Sprintf("PT%dH", minutes/60)
+	}
+	return fmt.Sprintf("PT%dM", minutes)
+}
@@ -0,0 +1,151 @@
+package ical
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func writeCalendar(t *testing.T, cal *Calendar) string {
+	t.Helper()
+	var b strings.Builder
+	if err := cal.Write(&b); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	return b.String()
+}
+
+func testEvent() Event {
+	start := time.Date(2026, 5, 1, 16, 30, 0, 0, time.UTC)
+	return Event{UID: "stop@myarea", Stamp: start, Start: start, End: start.Add(time.Hour), Summary: "Stop"}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"a, b; c", `a\, b\; c`},
+		{`back\slash`, `back\\slash`},
+		{"two\nlines", `two\nlines`},
+		{"crlf\r\nline", `crlf\nline`},
+		{"bell\x07 and nul\x00", "bell and nul"},
+		{"tab\tkept", "tab\tkept"},
+	}
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteFoldsLongLines(t *testing.T) {
+	event := testEvent()
+	event.Description = strings.Repeat("héllo wörld ", 30)
+	out := writeCalendar(t, &Calendar{ProdID: "-//test//EN", Events: []Event{event}})
+
+	if !strings.HasSuffix(out, "\r\n") {
+		t.Error("calendar should end with CRLF")
+	}
+	var unfolded strings.Builder
+	for i, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line %d is %d octets", i, len(line))
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a UTF-8 sequence", i)
+		}
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+		} else {
+			unfolded.WriteString("\n" + line)
+		}
+	}
+	if !strings.Contains(unfolded.String(), "\nDESCRIPTION:"+escapeText(event.Description)+"\n") {
+		t.Error("unfolding the lines should give back the description")
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	timed := testEvent()
+	timed.Location = "Tartine, 600 Guerrero St"
+	timed.HasGeo, timed.Latitude, timed.Longitude = true, 37.761, -122.424
+	timed.URL = "https://example.com/?a=1&b=2"
+	timed.Sequence = 3
+
+	allDay := testEvent()
+	allDay.UID = "day@myarea"
+	allDay.AllDay = true
+	allDay.Start = time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC)
+	allDay.End = allDay.Start.AddDate(0, 0, 1)
+
+	out := writeCalendar(t, &Calendar{ProdID: "-//test//EN", Name: "Trip", RefreshInterval: time.Hour, Events: []Event{timed, allDay}})
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H\r\n",
+		"DTSTART:20260501T163000Z\r\nDTEND:20260501T173000Z\r\n",
+		"SEQUENCE:3\r\n",
+		`LOCATION:Tartine\, 600 Guerrero St` + "\r\n",
+		"GEO:37.761000;-122.424000\r\n",
+		"URL;VALUE=URI:https://example.com/?a=1&b=2\r\n",
+		"DTSTART;VALUE=DATE:20260502\r\nDTEND;VALUE=DATE:20260503\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("calendar is missing %q", want)
+		}
+	}
+}
+
+func TestWriteRejectsInjection(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Event)
+		want   error
+	}{
+		{"CRLF in URL", func(e *Event) { e.URL = "https://example.com/\r\nBEGIN:VALARM" }, ErrInvalidURL},
+		{"javascript URL", func(e *Event) { e.URL = "javascript:alert(1)" }, ErrInvalidURL},
+		{"relative URL", func(e *Event) { e.URL = "/places/1" }, ErrInvalidURL},
+		{"CRLF in UID", func(e *Event) { e.UID = "x\r\nBEGIN:VALARM" }, ErrControlCharacter},
+	}
+	for _, tt := range tests {
+		event := testEvent()
+		tt.modify(&event)
+		var b strings.Builder
+		err := (&Calendar{ProdID: "-//test//EN", Events: []Event{event}}).Write(&b)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.want)
+		}
+		if b.Len() != 0 {
+			t.Errorf("%s: nothing should be written on error", tt.name)
+		}
+	}
+
+	// Text values are escaped rather than refused
+	event := testEvent()
+	event.Summary = "Cafe\r\nBEGIN:VALARM"
+	out := writeCalendar(t, &Calendar{ProdID: "-//test//EN", Events: []Event{event}})
+	if strings.Contains(out, "\r\nBEGIN:VALARM") {
+		t.Error("summary line breaks must be escaped")
+	}
+}
+
+func TestValidURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://example.com":        true,
+		"http://example.com/a?b=c":   true,
+		"ftp://example.com":          false,
+		"https://":                   false,
+		"https://exa mple.com":       false,
+		"https://example.com/\nfoo":  false,
+		"mailto:someone@example.com": false,
+		"HTTPS://EXAMPLE.COM/UPPER":  true,
+	}
+	for in, want := range tests {
+		if got := ValidURL(in); got != want {
+			t.Errorf("ValidURL(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
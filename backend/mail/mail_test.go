@@ -0,0 +1,50 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2024, time.March, 1, 9, 30, 0, 0, time.UTC)
+	got := string(Format("MyArea <noreply@example.com>", Message{
+		To:      "ana@example.com",
+		Subject: "Hello",
+		Body:    "Line one\nLine two\r\n",
+	}, date))
+
+	want := "From: MyArea <noreply@example.com>\r\n" +
+		"To: ana@example.com\r\n" +
+		"Subject: Hello\r\n" +
+		"Date: Fri, 01 Mar 2024 09:30:00 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"Line one\r\nLine two\r\n"
+	if got != want {
+		t.Errorf("Format =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestHeaderInjection(t *testing.T) {
+	for _, msg := range []Message{
+		{To: "ana@example.com\r\nBcc: eve@example.com", Subject: "Hi"},
+		{To: "ana@example.com", Subject: "Hi\nBcc: eve@example.com"},
+	} {
+		if err := (LogMailer{}).Send(context.Background(), msg); !errors.Is(err, ErrInvalidHeader) {
+			t.Errorf("LogMailer.Send(%q) = %v, want ErrInvalidHeader", msg.To+msg.Subject, err)
+		}
+		if err := (SMTPMailer{Addr: "127.0.0.1:0"}).Send(context.Background(), msg); !errors.Is(err, ErrInvalidHeader) {
+			t.Errorf("SMTPMailer.Send(%q) = %v, want ErrInvalidHeader", msg.To+msg.Subject, err)
+		}
+	}
+	if err := (LogMailer{}).Send(context.Background(), Message{To: "ana@example.com", Subject: "Hi", Body: "a\nb"}); err != nil {
+		t.Errorf("LogMailer.Send: %v", err)
+	}
+	if strings.Contains(string(Format("x", Message{Body: "a\nb"}, time.Now())), "a\nb") {
+		t.Error("bare line feeds should become CRLF")
+	}
+}
@@ -2,10 +2,22 @@ package main
 
 import (
 	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+	_ "time/tzdata" // city time zones must resolve even without system zoneinfo
+
 	"myarea-backend/database"
+	"myarea-backend/geo"
 	"myarea-backend/handlers"
+	"myarea-backend/hours"
+	"myarea-backend/mail"
+	"myarea-backend/media"
 	"myarea-backend/middleware"
-	"os"
+	"myarea-backend/models"
+	"myarea-backend/routing"
+	"myarea-backend/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -22,10 +34,38 @@ func main() {
 	// Initialize database
 	database.Connect()
 	database.Migrate()
+	database.SeedCities()
+	database.SeedCategories()
+	database.PromoteAdmins()
+	database.LinkLocationsToCities()
 	//database.SeedBayAreaLocations()
 
+	// Initialize upload storage
+	storage.Setup()
+
+	// Purge trashed locations after the retention period
+	retentionDays, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || retentionDays <= 0 {
+		retentionDays = 30
+	}
+	database.StartTrashRetention(time.Duration(retentionDays)*24*time.Hour, time.Hour)
+
+	// Initialize outgoing mail
+	mail.Setup()
+
+	// Initialize geocoder
+	geo.SetupGeocoder()
+
+	// Initialize travel router
+	routing.SetupRouter()
+
+	// Initialize the holiday calendar for PH opening hours
+	hours.SetupHolidays()
+
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
+		// Leave room for multipart overhead on top of the largest image
+		BodyLimit: media.MaxUploadBytes + 1<<20,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -52,6 +92,13 @@ func main() {
 		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
 	}))
 
+	// Serve uploads when they are stored on local disk
+	if local, ok := storage.Default.(*storage.LocalStore); ok {
+		if u, err := url.Parse(local.BaseURL); err == nil {
+			app.Static(u.Path, local.Dir)
+		}
+	}
+
 	// Health check endpoint
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
@@ -67,16 +114,115 @@ func main() {
 	auth := api.Group("/auth")
 	auth.Post("/register", handlers.Register)
 	auth.Post("/login", handlers.Login)
+	auth.Post("/verify-email", handlers.VerifyEmail)
 	auth.Get("/me", middleware.AuthRequired, handlers.GetProfile)
 	auth.Put("/me", middleware.AuthRequired, handlers.UpdateProfile)
+	auth.Post("/me/avatar", middleware.AuthRequired, handlers.UploadAvatar)
+	auth.Post("/me/verify-email", middleware.AuthRequired, handlers.SendEmailVerification)
 
 	// Location routes
 	locations := api.Group("/locations")
 	locations.Get("/", middleware.OptionalAuth, handlers.GetLocations)
+	locations.Get("/trash", middleware.AuthRequired, handlers.GetTrashedLocations)
+	locations.Post("/import", middleware.AuthRequired, handlers.ImportLocations)
+	locations.Get("/import/:id", middleware.AuthRequired, handlers.GetImportJob)
 	locations.Get("/:id", middleware.OptionalAuth, handlers.GetLocation)
 	locations.Post("/", middleware.AuthRequired, handlers.CreateLocation)
 	locations.Put("/:id", middleware.AuthRequired, handlers.UpdateLocation)
 	locations.Delete("/:id", middleware.AuthRequired, handlers.DeleteLocation)
+	locations.Post("/:id/image", middleware.AuthRequired, handlers.UploadLocationImage)
+	locations.Get("/:id/photos", middleware.OptionalAuth, handlers.GetLocationPhotos)
+	locations.Post("/:id/photos", middleware.AuthRequired, handlers.AddLocationPhoto)
+	locations.Put("/:id/photos/order", middleware.AuthRequired, handlers.ReorderLocationPhotos)
+	locations.Put("/:id/photos/:photoId", middleware.AuthRequired, handlers.UpdateLocationPhoto)
+	locations.Delete("/:id/photos/:photoId", middleware.AuthRequired, handlers.DeleteLocationPhoto)
+	locations.Post("/:id/restore", middleware.AuthRequired, handlers.RestoreLocation)
+	locations.Post("/:id/copy", middleware.AuthRequired, handlers.CopyLocation)
+	locations.Get("/:id/revisions", middleware.AuthRequired, handlers.GetLocationRevisions)
+	locations.Post("/:id/revisions/:rev/restore", middleware.AuthRequired, handlers.RestoreLocationRevision)
+	locations.Get("/:id/reviews", middleware.OptionalAuth, handlers.GetLocationReviews)
+	locations.Post("/:id/reviews", middleware.AuthRequired, handlers.CreateReview)
+	locations.Put("/:id/reviews/:reviewId", middleware.AuthRequired, handlers.UpdateReview)
+	locations.Delete("/:id/reviews/:reviewId", middleware.AuthRequired, handlers.DeleteReview)
+
+	// Friend routes
+	friends := api.Group("/friends", middleware.AuthRequired)
+	friends.Get("/", handlers.GetFriends)
+	friends.Post("/", handlers.AddFriend)
+	friends.Delete("/:username", handlers.RemoveFriend)
+
+	// Guide routes
+	guides := api.Group("/guides")
+	guides.Get("/", middleware.OptionalAuth, handlers.GetGuides)
+	guides.Get("/invitations", middleware.AuthRequired, handlers.GetGuideInvitations)
+	guides.Post("/invitations/:inviteId/accept", middleware.AuthRequired, handlers.AcceptGuideInvitation)
+	guides.Delete("/invitations/:inviteId", middleware.AuthRequired, handlers.DeclineGuideInvitation)
+	guides.Get("/:id", middleware.OptionalAuth, handlers.GetGuide)
+	guides.Get("/:id/itinerary", middleware.OptionalAuth, handlers.GetGuideItinerary)
+	guides.Get("/:id/itinerary.ics", middleware.OptionalAuth, handlers.GetGuideItineraryCalendar)
+	guides.Get("/:id/export", middleware.OptionalAuth, handlers.GetGuideExport)
+	guides.Post("/", middleware.AuthRequired, handlers.CreateGuide)
+	guides.Put("/:id", middleware.AuthRequired, handlers.UpdateGuide)
+	guides.Delete("/:id", middleware.AuthRequired, handlers.DeleteGuide)
+	guides.Get("/:id/forks", middleware.OptionalAuth, handlers.GetGuideForks)
+	guides.Post("/:id/fork", middleware.AuthRequired, handlers.ForkGuide)
+	guides.Post("/:id/optimize", middleware.AuthRequired, handlers.OptimizeGuide)
+	guides.Get("/:id/shares", middleware.AuthRequired, handlers.GetGuideShares)
+	guides.Post("/:id/shares", middleware.AuthRequired, handlers.CreateGuideShare)
+	guides.Delete("/:id/shares/:shareId", middleware.AuthRequired, handlers.RevokeGuideShare)
+	guides.Get("/:id/comments", middleware.AuthRequired, handlers.GetGuideComments)
+	guides.Delete("/:id/comments/:commentId", middleware.AuthRequired, handlers.DeleteGuideComment)
+	guides.Get("/:id/collaborators", middleware.AuthRequired, handlers.GetGuideCollaborators)
+	guides.Post("/:id/collaborators", middleware.AuthRequired, handlers.InviteGuideCollaborator)
+	guides.Put("/:id/collaborators/:collaboratorId", middleware.AuthRequired, handlers.UpdateGuideCollaborator)
+	guides.Delete("/:id/collaborators/:collaboratorId", middleware.AuthRequired, handlers.RemoveGuideCollaborator)
+	guides.Post("/:id/items", middleware.AuthRequired, handlers.AddGuideItem)
+	guides.Put("/:id/items/order", middleware.AuthRequired, handlers.ReorderGuideItems)
+	guides.Put("/:id/items/:itemId", middleware.AuthRequired, handlers.UpdateGuideItem)
+	guides.Delete("/:id/items/:itemId", middleware.AuthRequired, handlers.RemoveGuideItem)
+
+	// Share link routes (no login needed)
+	shared := api.Group("/shared", middleware.OptionalAuth)
+	shared.Get("/:token", handlers.GetSharedGuide)
+	shared.Get("/:token/itinerary", handlers.GetSharedGuideItinerary)
+	shared.Get("/:token/itinerary.ics", handlers.GetSharedGuideItineraryCalendar)
+	shared.Get("/:token/export", handlers.GetSharedGuideExport)
+	shared.Get("/:token/comments", handlers.GetSharedGuideComments)
+	shared.Post("/:token/comments", handlers.CreateSharedGuideComment)
+
+	// Saved location routes
+	saved := api.Group("/saved", middleware.AuthRequired)
+	saved.Get("/", handlers.GetSavedLocations)
+	saved.Post("/", handlers.SaveLocation)
+	saved.Get("/lists", handlers.GetSavedLists)
+	saved.Post("/lists", handlers.CreateSavedList)
+	saved.Put("/lists/:id", handlers.UpdateSavedList)
+	saved.Delete("/lists/:id", handlers.DeleteSavedList)
+	saved.Delete("/:locationId", handlers.UnsaveLocation)
+
+	// City routes
+	cities := api.Group("/cities")
+	cities.Get("/", handlers.GetCities)
+	cities.Get("/:slug", handlers.GetCity)
+
+	// Category routes
+	api.Get("/categories", handlers.GetCategories)
+
+	// Tag routes
+	api.Get("/tags", handlers.GetTags)
+
+	// Admin routes
+	admin := api.Group("/admin", middleware.AuthRequired, middleware.AdminRequired)
+	admin.Post("/categories", handlers.CreateCategory)
+	admin.Put("/categories/:id", handlers.UpdateCategory)
+	admin.Delete("/categories/:id", handlers.DeleteCategory)
+	admin.Get("/tags/aliases", handlers.GetTagAliases)
+	admin.Delete("/tags/aliases/:alias", handlers.DeleteTagAlias)
+	admin.Post("/tags/merge", handlers.MergeTags)
+
+	// Moderation routes
+	moderation := api.Group("/moderation", middleware.AuthRequired, middleware.RequireRole(models.RoleModerator, models.RoleAdmin))
+	moderation.Post("/locations/merge", handlers.MergeLocations)
 
 	// User routes
 	users := api.Group("/users")
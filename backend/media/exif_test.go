@@ -0,0 +1,140 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// exifSegment builds an APP1 segment holding an orientation tag
+func exifSegment(order binary.ByteOrder, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+	binary.Write(&tiff, order, uint16(42))
+	binary.Write(&tiff, order, uint32(8)) // IFD0 offset
+	binary.Write(&tiff, order, uint16(2)) // entries
+	// An unrelated tag first: ImageWidth
+	binary.Write(&tiff, order, []uint16{0x0100, 3})
+	binary.Write(&tiff, order, uint32(1))
+	binary.Write(&tiff, order, []uint16{640, 0})
+	// Orientation, SHORT, count 1
+	binary.Write(&tiff, order, []uint16{0x0112, 3})
+	binary.Write(&tiff, order, uint32(1))
+	binary.Write(&tiff, order, []uint16{orientation, 0})
+	binary.Write(&tiff, order, uint32(0)) // no next IFD
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// withExif inserts an EXIF segment right after the JPEG's SOI marker
+func withExif(jpg []byte, order binary.ByteOrder, orientation uint16) []byte {
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, exifSegment(order, orientation)...)
+	return append(out, jpg[2:]...)
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	plain := encodeJPEG(t, image.NewRGBA(image.Rect(0, 0, 4, 2)))
+
+	if got := jpegOrientation(plain); got != 1 {
+		t.Errorf("no EXIF: orientation %d, want 1", got)
+	}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for orientation := uint16(1); orientation <= 8; orientation++ {
+			if got := jpegOrientation(withExif(plain, order, orientation)); got != int(orientation) {
+				t.Errorf("%v orientation %d: got %d", order, orientation, got)
+			}
+		}
+	}
+	if got := jpegOrientation(withExif(plain, binary.BigEndian, 9)); got != 1 {
+		t.Errorf("out-of-range orientation: got %d, want 1", got)
+	}
+
+	// Truncated and malformed data must not panic
+	broken := withExif(plain, binary.LittleEndian, 6)
+	for n := 0; n < 60; n++ {
+		jpegOrientation(broken[:n])
+	}
+	if got := jpegOrientation([]byte("not a jpeg")); got != 1 {
+		t.Errorf("non-JPEG: got %d", got)
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// A 3x2 image with a distinct color in each pixel
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			src.Set(x, y, color.RGBA{R: uint8(x * 100), G: uint8(y * 100), A: 255})
+		}
+	}
+	topLeft := color.RGBA{R: 0, G: 0, A: 255}
+
+	tests := []struct {
+		orientation int
+		w, h        int
+		// where the source's top-left pixel ends up
+		x, y int
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+	}
+	for _, tt := range tests {
+		out := applyOrientation(src, tt.orientation)
+		b := out.Bounds()
+		if b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("orientation %d: size %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), tt.w, tt.h)
+			continue
+		}
+		if got := color.RGBAModel.Convert(out.At(tt.x, tt.y)); got != topLeft {
+			t.Errorf("orientation %d: pixel (%d,%d) = %v, want the source's top-left", tt.orientation, tt.x, tt.y, got)
+		}
+	}
+}
+
+func TestProcessAppliesOrientationAndStripsExif(t *testing.T) {
+	data := withExif(encodeJPEG(t, image.NewRGBA(image.Rect(0, 0, 40, 20))), binary.LittleEndian, 6)
+
+	result, err := Process(data, []Variant{{Name: "thumb", MaxSize: 10}})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if result.Width != 20 || result.Height != 40 {
+		t.Errorf("source size %dx%d, want the rotated 20x40", result.Width, result.Height)
+	}
+	thumb := result.Variants[0]
+	if thumb.Width != 5 || thumb.Height != 10 || thumb.ContentType != "image/jpeg" {
+		t.Errorf("thumb = %dx%d %s", thumb.Width, thumb.Height, thumb.ContentType)
+	}
+	if bytes.Contains(thumb.Data, []byte("Exif\x00\x00")) {
+		t.Error("re-encoded variant still carries EXIF")
+	}
+	if _, err := Process([]byte("GIF89a not really"), nil); err == nil {
+		t.Error("Process accepted a broken image")
+	}
+}
@@ -0,0 +1,208 @@
+// Package media validates uploaded images and produces resized, metadata-free variants.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// MaxUploadBytes is the largest image accepted
+const MaxUploadBytes = 10 << 20
+
+// maxPixels guards against decompression bombs
+const maxPixels = 40_000_000
+
+// jpegQuality is used for all JPEG variants
+const jpegQuality = 85
+
+var (
+	// ErrTooLarge is returned for uploads over MaxUploadBytes or maxPixels
+	ErrTooLarge = errors.New("image is too large")
+	// ErrUnsupportedType is returned for anything but JPEG, PNG and GIF
+	ErrUnsupportedType = errors.New("image must be a JPEG, PNG or GIF")
+)
+
+// Variant describes a resized copy to generate
+type Variant struct {
+	Name    string
+	MaxSize int // longest edge in pixels
+}
+
+// LocationVariants are generated for location photos
+var LocationVariants = []Variant{
+	{Name: "thumb", MaxSize: 320},
+	{Name: "medium", MaxSize: 960},
+	{Name: "large", MaxSize: 1920},
+}
+
+// AvatarVariants are generated for profile pictures
+var AvatarVariants = []Variant{
+	{Name: "thumb", MaxSize: 96},
+	{Name: "medium", MaxSize: 400},
+}
+
+// Output is one encoded variant
+type Output struct {
+	Name        string
+	Width       int
+	Height      int
+	ContentType string
+	Extension   string
+	Data        []byte
+}
+
+// Result is a processed upload
+type Result struct {
+	Width    int // of the (orientation-corrected) source
+	Height   int
+	Variants []Output
+}
+
+// Process validates an uploaded image and encodes each variant. Re-encoding
+// drops all metadata, including EXIF GPS coordinates; the EXIF orientation
+// is applied to the pixels first so photos stay upright.
+func Process(data []byte, variants []Variant) (*Result, error) {
+	if len(data) > MaxUploadBytes {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	var img image.Image
+	switch contentType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+		if err == nil {
+			img = applyOrientation(img, jpegOrientation(data))
+		}
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+
+	// Keep PNG for images with transparency; everything else becomes JPEG
+	keepPNG := contentType != "image/jpeg" && hasAlpha(img)
+
+	bounds := img.Bounds()
+	result := &Result{Width: bounds.Dx(), Height: bounds.Dy()}
+	for _, v := range variants {
+		resized := Fit(img, v.MaxSize)
+		out := Output{Name: v.Name, Width: resized.Bounds().Dx(), Height: resized.Bounds().Dy()}
+
+		var buf bytes.Buffer
+		if keepPNG {
+			err = png.Encode(&buf, resized)
+			out.ContentType, out.Extension = "image/png", "png"
+		} else {
+			err = jpeg.Encode(&buf, flatten(resized), &jpeg.Options{Quality: jpegQuality})
+			out.ContentType, out.Extension = "image/jpeg", "jpg"
+		}
+		if err != nil {
+			return nil, err
+		}
+		out.Data = buf.Bytes()
+		result.Variants = append(result.Variants, out)
+	}
+	return result, nil
+}
+
+// Fit scales img down so its longest edge is at most maxSize, averaging the
+// source pixels that fall in each destination pixel. Smaller images are
+// returned unchanged.
+func Fit(img image.Image, maxSize int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if maxSize <= 0 || (w <= maxSize && h <= maxSize) {
+		return img
+	}
+
+	var dw, dh int
+	if w >= h {
+		dw, dh = maxSize, max(1, h*maxSize/w)
+	} else {
+		dw, dh = max(1, w*maxSize/h), maxSize
+	}
+
+	src := toRGBA(img)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		sy0 := b.Min.Y + y*h/dh
+		sy1 := max(sy0+1, b.Min.Y+(y+1)*h/dh)
+		for x := 0; x < dw; x++ {
+			sx0 := b.Min.X + x*w/dw
+			sx1 := max(sx0+1, b.Min.X+(x+1)*w/dw)
+
+			var r, g, bl, a, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				i := src.PixOffset(sx0, sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint32(src.Pix[i])
+					g += uint32(src.Pix[i+1])
+					bl += uint32(src.Pix[i+2])
+					a += uint32(src.Pix[i+3])
+					n++
+					i += 4
+				}
+			}
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(bl / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba
+}
+
+// flatten composites an image onto white for formats without alpha
+func flatten(img image.Image) image.Image {
+	if !hasAlpha(img) {
+		return img
+	}
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Over)
+	return out
+}
+
+// hasAlpha reports whether any pixel is not fully opaque
+func hasAlpha(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return !o.Opaque()
+	}
+	return true
+}
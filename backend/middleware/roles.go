@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"myarea-backend/database"
+	"myarea-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequireRole allows the request through only if the authenticated user has
+// one of the given roles. It must run after AuthRequired.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("user_id").(uuid.UUID)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Authentication required",
+			})
+		}
+
+		var user models.User
+		if err := database.DB.Select("id", "role").First(&user, userID).Error; err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+
+		for _, role := range roles {
+			if user.Role == role {
+				c.Locals("user_role", user.Role)
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You do not have permission to perform this action",
+		})
+	}
+}
+
+// AdminRequired allows only admins through
+var AdminRequired = RequireRole(models.RoleAdmin)
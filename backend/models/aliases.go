@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LocationAlias redirects the ID of a merged-away location to the location it was merged into
+type LocationAlias struct {
+	OldID      uuid.UUID `json:"old_id" gorm:"type:uuid;primaryKey"`
+	LocationID uuid.UUID `json:"location_id" gorm:"type:uuid;not null;index"`
+	MergedBy   uuid.UUID `json:"merged_by" gorm:"type:uuid;not null"`
+	CreatedAt  time.Time `json:"created_at"`
+}
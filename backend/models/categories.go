@@ -0,0 +1,82 @@
+package models
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Category is an admin-managed location category
+type Category struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Slug        string     `json:"slug" gorm:"uniqueIndex;not null"`
+	DisplayName string     `json:"display_name" gorm:"not null"`
+	Icon        string     `json:"icon"`
+	Color       string     `json:"color"`
+	ParentID    *uuid.UUID `json:"parent_id" gorm:"type:uuid;index"`
+	SortOrder   int        `json:"sort_order" gorm:"default:0"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Foreign key
+	Parent *Category `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
+}
+
+// categoryCacheTTL is how long loaded category slugs are trusted
+const categoryCacheTTL = 5 * time.Minute
+
+// CategoryLoader loads the current category slugs from storage. It is set by
+// the database package; when nil, the built-in categories are used.
+var CategoryLoader func() ([]string, error)
+
+var categoryCache struct {
+	sync.RWMutex
+	slugs    map[string]bool
+	loadedAt time.Time
+}
+
+// InvalidateCategoryCache forces the next lookup to reload categories
+func InvalidateCategoryCache() {
+	categoryCache.Lock()
+	categoryCache.slugs = nil
+	categoryCache.Unlock()
+}
+
+// categorySlugs returns the cached set of valid category slugs
+func categorySlugs() map[string]bool {
+	categoryCache.RLock()
+	slugs, loadedAt := categoryCache.slugs, categoryCache.loadedAt
+	categoryCache.RUnlock()
+	if slugs != nil && time.Since(loadedAt) < categoryCacheTTL {
+		return slugs
+	}
+
+	categoryCache.Lock()
+	defer categoryCache.Unlock()
+	if categoryCache.slugs != nil && time.Since(categoryCache.loadedAt) < categoryCacheTTL {
+		return categoryCache.slugs
+	}
+
+	var list []string
+	if CategoryLoader != nil {
+		loaded, err := CategoryLoader()
+		if err == nil {
+			list = loaded
+		} else if categoryCache.slugs != nil {
+			// Keep serving the stale set rather than rejecting everything
+			return categoryCache.slugs
+		}
+	}
+	if len(list) == 0 {
+		list = ValidCategories()
+	}
+
+	slugs = make(map[string]bool, len(list))
+	for _, slug := range list {
+		slugs[slug] = true
+	}
+	categoryCache.slugs = slugs
+	categoryCache.loadedAt = time.Now()
+	return slugs
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Guide roles. The owner is the guide's author; collaborators are viewers or editors.
+const (
+	GuideRoleOwner  = "owner"
+	GuideRoleEditor = "editor"
+	GuideRoleViewer = "viewer"
+)
+
+// GuideCollaborator gives another user access to a guide. It starts as an
+// invitation and takes effect once AcceptedAt is set. Invitations to an email
+// address without an account have no UserID until someone with that email
+// accepts them.
+type GuideCollaborator struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	GuideID     uuid.UUID  `json:"guide_id" gorm:"type:uuid;not null;uniqueIndex:idx_guide_collaborator_user;uniqueIndex:idx_guide_collaborator_email"`
+	UserID      *uuid.UUID `json:"user_id" gorm:"type:uuid;uniqueIndex:idx_guide_collaborator_user;index"`
+	Email       *string    `json:"email,omitempty" gorm:"uniqueIndex:idx_guide_collaborator_email"`
+	Role        string     `json:"role" gorm:"not null;default:viewer"`
+	InvitedByID uuid.UUID  `json:"invited_by_id" gorm:"type:uuid;not null"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Foreign keys
+	User  *User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Guide *Guide `json:"guide,omitempty" gorm:"foreignKey:GuideID"`
+}
+
+// IsValidCollaboratorRole checks if a role can be given to a collaborator
+func IsValidCollaboratorRole(role string) bool {
+	return role == GuideRoleEditor || role == GuideRoleViewer
+}
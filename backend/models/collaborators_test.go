@@ -0,0 +1,18 @@
+package models
+
+import "testing"
+
+func TestIsValidCollaboratorRole(t *testing.T) {
+	for role, want := range map[string]bool{
+		GuideRoleEditor: true,
+		GuideRoleViewer: true,
+		// Ownership can't be handed out through an invitation
+		GuideRoleOwner: false,
+		"":             false,
+		"Editor":       false,
+	} {
+		if got := IsValidCollaboratorRole(role); got != want {
+			t.Errorf("IsValidCollaboratorRole(%q) = %v, want %v", role, got, want)
+		}
+	}
+}
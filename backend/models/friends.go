@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Friendship lets a user share friends-only content with another user.
+// It is one-directional: UserID shares with FriendID.
+type Friendship struct {
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	FriendID  uuid.UUID `json:"friend_id" gorm:"type:uuid;primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Foreign key
+	Friend User `json:"friend,omitempty" gorm:"foreignKey:FriendID"`
+}
+
+// Location visibility values
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityFriends  = "friends"
+	VisibilityPrivate  = "private"
+)
+
+// IsValidVisibility checks if a visibility value is valid
+func IsValidVisibility(visibility string) bool {
+	switch visibility {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityFriends, VisibilityPrivate:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GuideItem is one stop in a guide. Items are ordered by Position; Day, Slot,
+// StartTime and DurationMinutes optionally place it in the trip's itinerary.
+type GuideItem struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	GuideID         uuid.UUID `json:"guide_id" gorm:"type:uuid;not null;uniqueIndex:idx_guide_item_location;index:idx_guide_item_position"`
+	LocationID      uuid.UUID `json:"location_id" gorm:"type:uuid;not null;uniqueIndex:idx_guide_item_location;index"`
+	Position        int       `json:"position" gorm:"not null;index:idx_guide_item_position"`
+	Note            *string   `json:"note"`
+	Day             *int      `json:"day" gorm:"check:day >= 1"`
+	Slot            *string   `json:"slot"`
+	StartTime       *string   `json:"start_time" gorm:"size:5"`
+	DurationMinutes *int      `json:"duration_minutes" gorm:"check:duration_minutes > 0"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Foreign key; purging a location removes it from guides
+	Location *Location `json:"location,omitempty" gorm:"foreignKey:LocationID;constraint:OnDelete:CASCADE"`
+}
+
+// Longest guide title and description accepted
+const (
+	MaxGuideTitle       = 200
+	MaxGuideDescription = 5000
+)
+
+// MaxGuideItems is the most stops a guide can have
+const MaxGuideItems = 200
+
+// MaxGuideItemNote is the longest stop note accepted
+const MaxGuideItemNote = 2000
+
+// MaxTripDays is the longest trip a guide can be planned for
+const MaxTripDays = 60
+
+// Itinerary time slots, in the order they happen in a day
+const (
+	SlotMorning   = "morning"
+	SlotAfternoon = "afternoon"
+	SlotEvening   = "evening"
+	SlotNight     = "night"
+)
+
+// TimeSlots returns the itinerary time slots in order
+func TimeSlots() []string {
+	return []string{SlotMorning, SlotAfternoon, SlotEvening, SlotNight}
+}
+
+// TripDays returns the number of days between a guide's start and end dates,
+// inclusive, or 0 if the dates aren't set
+func (g *Guide) TripDays() int {
+	if g.StartDate == nil || g.EndDate == nil {
+		return 0
+	}
+	return int(g.EndDate.Sub(*g.StartDate).Hours()/24) + 1
+}
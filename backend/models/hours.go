@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"myarea-backend/hours"
+)
+
+// DefaultTimeZone is used for cities without a time zone and locations without a city
+const DefaultTimeZone = "America/Los_Angeles"
+
+// Zone returns the city's time zone, falling back to DefaultTimeZone
+func (c *City) Zone() *time.Location {
+	if c != nil && c.TimeZone != "" {
+		if loc, err := time.LoadLocation(c.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return DefaultZone()
+}
+
+// DefaultZone returns DefaultTimeZone, or UTC if it can't be loaded
+func DefaultZone() *time.Location {
+	loc, err := time.LoadLocation(DefaultTimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Schedule parses the location's opening hours, or returns nil if it has none.
+// Stored hours are validated on save, so a parse failure is treated as unknown.
+func (l *Location) Schedule() *hours.Schedule {
+	if l.OpeningHours == nil || *l.OpeningHours == "" {
+		return nil
+	}
+	schedule, err := hours.Parse(*l.OpeningHours)
+	if err != nil {
+		return nil
+	}
+	return schedule
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Image kinds
+const (
+	ImageKindLocation = "location"
+	ImageKindAvatar   = "avatar"
+)
+
+// Image is an uploaded picture and its stored variants
+type Image struct {
+	ID        uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OwnerID   uuid.UUID     `json:"owner_id" gorm:"type:uuid;not null;index"`
+	Kind      string        `json:"kind" gorm:"not null"`
+	SubjectID uuid.UUID     `json:"subject_id" gorm:"type:uuid;not null;index"`
+	Width     int           `json:"width"`
+	Height    int           `json:"height"`
+	Variants  ImageVariants `json:"variants" gorm:"type:jsonb"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// ImageVariant is one stored size of an image
+type ImageVariant struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// ImageVariants maps variant names (thumb, medium, large) to stored files
+type ImageVariants map[string]ImageVariant
+
+// URL returns the URL of the named variant, falling back to the largest one stored
+func (i *Image) URL(name string) string {
+	if v, ok := i.Variants[name]; ok {
+		return v.URL
+	}
+	var best ImageVariant
+	for _, v := range i.Variants {
+		if v.Width*v.Height > best.Width*best.Height {
+			best = v
+		}
+	}
+	return best.URL
+}
+
+// Value implements driver.Valuer
+func (v ImageVariants) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// Scan implements sql.Scanner
+func (v *ImageVariants) Scan(value interface{}) error {
+	return scanJSON(value, v)
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Import job statuses
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// Import row outcomes
+const (
+	ImportRowCreated = "created"
+	ImportRowValid   = "valid"
+	ImportRowSkipped = "skipped"
+	ImportRowFailed  = "failed"
+)
+
+// ImportJob tracks a bulk import of locations
+type ImportJob struct {
+	ID         uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID    `json:"user_id" gorm:"type:uuid;not null;index"`
+	Format     string       `json:"format" gorm:"not null"`
+	Filename   string       `json:"filename"`
+	DryRun     bool         `json:"dry_run"`
+	Status     string       `json:"status" gorm:"not null;default:pending"`
+	Total      int          `json:"total"`
+	Created    int          `json:"created"`
+	Skipped    int          `json:"skipped"`
+	Failed     int          `json:"failed"`
+	Error      *string      `json:"error,omitempty"`
+	Rows       ImportReport `json:"rows" gorm:"type:jsonb"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+}
+
+// ImportRowResult is the outcome of importing one row
+type ImportRowResult struct {
+	Row        int         `json:"row"`
+	Name       string      `json:"name,omitempty"`
+	Status     string      `json:"status"`
+	LocationID *uuid.UUID  `json:"location_id,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Warnings   []string    `json:"warnings,omitempty"`
+	Preview    interface{} `json:"preview,omitempty"`
+}
+
+// ImportReport is the per-row report of an import
+type ImportReport []ImportRowResult
+
+// Value implements driver.Valuer
+func (r ImportReport) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+// Scan implements sql.Scanner
+func (r *ImportReport) Scan(value interface{}) error {
+	return scanJSON(value, r)
+}
@@ -1,10 +1,15 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
+	"myarea-backend/geo"
+
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"gorm.io/gorm"
 )
 
 // User represents a user in the system
@@ -14,10 +19,20 @@ type User struct {
 	Username    string    `json:"username" gorm:"uniqueIndex;not null"`
 	DisplayName string    `json:"display_name" gorm:"not null"`
 	AvatarURL   *string   `json:"avatar_url"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Role        string    `json:"role" gorm:"not null;default:user"`
+	// EmailVerifiedAt is set once the user has followed a verification link
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
+// User roles
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 // Location represents a recommended place
 type Location struct {
 	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -29,16 +44,29 @@ type Location struct {
 	Latitude    float64   `json:"latitude" gorm:"not null"`
 	Longitude   float64   `json:"longitude" gorm:"not null"`
 	City        string    `json:"city" gorm:"not null"`
+	CityID      *uuid.UUID `json:"city_id" gorm:"type:uuid;index"`
 	Rating      *int      `json:"rating" gorm:"check:rating >= 1 AND rating <= 5"`
 	PriceLevel  *int      `json:"price_level" gorm:"check:price_level >= 1 AND price_level <= 4"`
-	Tags        pq.StringArray  `json:"tags" gorm:"type:text[]"`
+	AverageRating *float64 `json:"average_rating"`
+	ReviewCount int       `json:"review_count" gorm:"not null;default:0"`
+	Tags        pq.StringArray  `json:"tags" gorm:"type:text[];index:idx_locations_tags,type:gin"`
 	ImageURL    *string   `json:"image_url"`
 	WebsiteURL  *string   `json:"website_url"`
+	Visibility  string    `json:"visibility" gorm:"not null;default:public;index"`
+	OpeningHours *string  `json:"opening_hours"`
+	// Attribution for locations copied from another user's
+	CopiedFromID     *uuid.UUID `json:"copied_from_id" gorm:"type:uuid;index"`
+	CopiedFromUserID *uuid.UUID `json:"copied_from_user_id" gorm:"type:uuid"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// Foreign key
 	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+
+	// Gallery; CoverPhoto is preloaded with position = 0
+	CoverPhoto *LocationPhoto `json:"cover_photo,omitempty" gorm:"foreignKey:LocationID"`
+	Photos     []LocationPhoto `json:"photos,omitempty" gorm:"foreignKey:LocationID"`
 }
 
 // Guide represents a collection of locations
@@ -48,13 +76,89 @@ type Guide struct {
 	Title       string      `json:"title" gorm:"not null"`
 	Description *string     `json:"description"`
 	City        string      `json:"city" gorm:"not null"`
+	CityID      *uuid.UUID  `json:"city_id" gorm:"type:uuid;index"`
 	IsPublic    bool        `json:"is_public" gorm:"default:true"`
-	LocationIDs []uuid.UUID `json:"location_ids" gorm:"type:uuid[]"`
+	StartDate   *time.Time  `json:"start_date" gorm:"type:date"`
+	EndDate     *time.Time  `json:"end_date" gorm:"type:date"`
+	// Version is bumped on every change, for optimistic concurrency between editors
+	Version     int         `json:"version" gorm:"not null;default:1"`
+	// Attribution for forks of another guide, and how often this one was forked
+	ForkedFromID     *uuid.UUID `json:"forked_from_id" gorm:"type:uuid;index"`
+	ForkedFromUserID *uuid.UUID `json:"forked_from_user_id" gorm:"type:uuid"`
+	ForkCount        int        `json:"fork_count" gorm:"not null;default:0"`
 	CreatedAt   time.Time   `json:"created_at"`
 	UpdatedAt   time.Time   `json:"updated_at"`
 
-	// Foreign key
-	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	// Foreign keys
+	User           User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	ForkedFromUser *User `json:"forked_from_user,omitempty" gorm:"foreignKey:ForkedFromUserID"`
+
+	// Stops, ordered by position
+	Items []GuideItem `json:"items,omitempty" gorm:"foreignKey:GuideID;constraint:OnDelete:CASCADE"`
+
+	// Secret share links
+	Shares []GuideShare `json:"-" gorm:"foreignKey:GuideID;constraint:OnDelete:CASCADE"`
+
+	// Comments left through share links
+	Comments []GuideComment `json:"-" gorm:"foreignKey:GuideID;constraint:OnDelete:CASCADE"`
+
+	// Collaborators and pending invitations
+	Collaborators []GuideCollaborator `json:"-" gorm:"foreignKey:GuideID;constraint:OnDelete:CASCADE"`
+}
+
+// City is a canonical city or region that locations and guides belong to
+type City struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Slug      string         `json:"slug" gorm:"uniqueIndex;not null"`
+	Name      string         `json:"name" gorm:"not null"`
+	Region    string         `json:"region"`
+	Country   string         `json:"country"`
+	Aliases   pq.StringArray `json:"aliases" gorm:"type:text[]"`
+	Boundary  geo.Polygon    `json:"boundary" gorm:"type:jsonb"`
+	Bounds    geo.BBox       `json:"bounds" gorm:"embedded;embeddedPrefix:bounds_"`
+	Viewport  Viewport       `json:"viewport" gorm:"embedded;embeddedPrefix:viewport_"`
+	IsDefault bool           `json:"is_default" gorm:"default:false"`
+	TimeZone  string         `json:"time_zone" gorm:"not null;default:America/Los_Angeles"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// Viewport is the initial map position for a city
+type Viewport struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Zoom      float64 `json:"zoom"`
+}
+
+// BeforeSave normalizes aliases, checks the time zone and keeps the bounding
+// box in sync with the boundary
+func (c *City) BeforeSave(tx *gorm.DB) error {
+	if c.TimeZone == "" {
+		c.TimeZone = DefaultTimeZone
+	}
+	if _, err := time.LoadLocation(c.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", c.TimeZone, err)
+	}
+
+	aliases := make(pq.StringArray, 0, len(c.Aliases))
+	for _, alias := range c.Aliases {
+		if a := NormalizeCityName(alias); a != "" {
+			aliases = append(aliases, a)
+		}
+	}
+	c.Aliases = aliases
+	c.Bounds = c.Boundary.Bounds()
+	return nil
+}
+
+// NormalizeCityName lowercases and collapses whitespace for alias matching
+func NormalizeCityName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// NormalizeCityDisplay trims and collapses whitespace without changing case
+func NormalizeCityDisplay(name string) string {
+	return strings.Join(strings.Fields(name), " ")
 }
 
 // LocationCategory enum values
@@ -72,7 +176,8 @@ const (
 	CategoryOther         = "other"
 )
 
-// ValidCategories returns all valid location categories
+// ValidCategories returns the built-in location categories. They seed the
+// categories table, which is the source of truth once populated.
 func ValidCategories() []string {
 	return []string{
 		CategoryRestaurant,
@@ -89,12 +194,7 @@ func ValidCategories() []string {
 	}
 }
 
-// IsValidCategory checks if a category is valid
+// IsValidCategory checks if a category exists, using a cached lookup of the categories table
 func IsValidCategory(category string) bool {
-	for _, valid := range ValidCategories() {
-		if category == valid {
-			return true
-		}
-	}
-	return false
+	return categorySlugs()[category]
 }
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LocationPhoto is one picture in a location's gallery. The photo at
+// position 0 is the cover.
+type LocationPhoto struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LocationID uuid.UUID `json:"location_id" gorm:"type:uuid;not null;index:idx_location_photo_position"`
+	ImageID    uuid.UUID `json:"image_id" gorm:"type:uuid;not null"`
+	Position   int       `json:"position" gorm:"not null;index:idx_location_photo_position"`
+	Caption    *string   `json:"caption"`
+	UploaderID uuid.UUID `json:"uploader_id" gorm:"type:uuid;not null"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Foreign keys
+	Image    Image `json:"image" gorm:"foreignKey:ImageID"`
+	Uploader User  `json:"uploader,omitempty" gorm:"foreignKey:UploaderID"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Review is one user's rating and notes for a location. Each user can review
+// a location once; the location keeps the aggregate in AverageRating and ReviewCount.
+type Review struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LocationID uuid.UUID  `json:"location_id" gorm:"type:uuid;not null;uniqueIndex:idx_review_location_user;index"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_review_location_user"`
+	Rating     int        `json:"rating" gorm:"not null;check:rating >= 1 AND rating <= 5"`
+	Text       *string    `json:"text"`
+	VisitedOn  *time.Time `json:"visited_on" gorm:"type:date"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Foreign key
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// MaxReviewLength is the longest review text accepted
+const MaxReviewLength = 5000
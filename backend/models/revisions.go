@@ -0,0 +1,163 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// LocationRevision records one change to a location
+type LocationRevision struct {
+	ID           uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LocationID   uuid.UUID        `json:"location_id" gorm:"type:uuid;not null;uniqueIndex:idx_location_revision"`
+	Number       int              `json:"number" gorm:"not null;uniqueIndex:idx_location_revision"`
+	AuthorID     uuid.UUID        `json:"author_id" gorm:"type:uuid;not null"`
+	Changes      FieldChanges     `json:"changes" gorm:"type:jsonb"`
+	Snapshot     LocationSnapshot `json:"snapshot" gorm:"type:jsonb"`
+	RestoredFrom *int             `json:"restored_from,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+
+	// Foreign key
+	Author User `json:"author,omitempty" gorm:"foreignKey:AuthorID"`
+}
+
+// LocationSnapshot holds the user-editable fields of a location
+type LocationSnapshot struct {
+	Name         string         `json:"name"`
+	Description  *string        `json:"description"`
+	Category     string         `json:"category"`
+	Address      string         `json:"address"`
+	Latitude     float64        `json:"latitude"`
+	Longitude    float64        `json:"longitude"`
+	City         string         `json:"city"`
+	Rating       *int           `json:"rating"`
+	PriceLevel   *int           `json:"price_level"`
+	Tags         pq.StringArray `json:"tags"`
+	ImageURL     *string        `json:"image_url"`
+	WebsiteURL   *string        `json:"website_url"`
+	Visibility   string         `json:"visibility"`
+	OpeningHours *string        `json:"opening_hours"`
+}
+
+// FieldChange is the before and after value of a single field
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// FieldChanges maps a field's JSON name to its change
+type FieldChanges map[string]FieldChange
+
+// SnapshotLocation captures the editable fields of a location
+func SnapshotLocation(l *Location) LocationSnapshot {
+	return LocationSnapshot{
+		Name:         l.Name,
+		Description:  l.Description,
+		Category:     l.Category,
+		Address:      l.Address,
+		Latitude:     l.Latitude,
+		Longitude:    l.Longitude,
+		City:         l.City,
+		Rating:       l.Rating,
+		PriceLevel:   l.PriceLevel,
+		Tags:         l.Tags,
+		ImageURL:     l.ImageURL,
+		WebsiteURL:   l.WebsiteURL,
+		Visibility:   l.Visibility,
+		OpeningHours: l.OpeningHours,
+	}
+}
+
+// ApplyTo copies the snapshot's fields onto a location
+func (s LocationSnapshot) ApplyTo(l *Location) {
+	l.Name = s.Name
+	l.Description = s.Description
+	l.Category = s.Category
+	l.Address = s.Address
+	l.Latitude = s.Latitude
+	l.Longitude = s.Longitude
+	l.City = s.City
+	l.Rating = s.Rating
+	l.PriceLevel = s.PriceLevel
+	l.Tags = s.Tags
+	l.ImageURL = s.ImageURL
+	l.WebsiteURL = s.WebsiteURL
+	l.Visibility = s.Visibility
+	l.OpeningHours = s.OpeningHours
+}
+
+// DiffSnapshots returns the fields that differ between two snapshots
+func DiffSnapshots(before, after LocationSnapshot) FieldChanges {
+	changes := FieldChanges{}
+	b := reflect.ValueOf(before)
+	a := reflect.ValueOf(after)
+	t := b.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		oldValue := derefValue(b.Field(i))
+		newValue := derefValue(a.Field(i))
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		changes[name] = FieldChange{Old: oldValue, New: newValue}
+	}
+	return changes
+}
+
+// derefValue unwraps pointers so nil and empty values compare sensibly
+func derefValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		return v.Elem().Interface()
+	}
+	if v.Kind() == reflect.Slice && v.Len() == 0 {
+		return nil
+	}
+	return v.Interface()
+}
+
+// Value implements driver.Valuer
+func (s LocationSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan implements sql.Scanner
+func (s *LocationSnapshot) Scan(value interface{}) error {
+	return scanJSON(value, s)
+}
+
+// Value implements driver.Valuer
+func (f FieldChanges) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return json.Marshal(f)
+}
+
+// Scan implements sql.Scanner
+func (f *FieldChanges) Scan(value interface{}) error {
+	return scanJSON(value, f)
+}
+
+// scanJSON decodes a json/jsonb column into dest
+func scanJSON(value interface{}, dest interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return errors.New("models: unsupported JSON column value")
+	}
+}
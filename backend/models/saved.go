@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SavedList is a user's named collection of saved locations, e.g. "Want to go"
+type SavedList struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_saved_list_name"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex:idx_saved_list_name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SavedLocation bookmarks a location for a user, optionally in one of their
+// lists. A location can be saved once without a list and once per list,
+// enforced by unique indexes created in database.Migrate.
+type SavedLocation struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	LocationID uuid.UUID  `json:"location_id" gorm:"type:uuid;not null;index"`
+	ListID     *uuid.UUID `json:"list_id" gorm:"type:uuid;index"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// Foreign key
+	Location *Location `json:"location,omitempty" gorm:"foreignKey:LocationID"`
+}
+
+// MaxSavedListName is the longest list name accepted
+const MaxSavedListName = 80
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Share link permissions
+const (
+	SharePermissionView    = "view"
+	SharePermissionComment = "comment"
+)
+
+// GuideShare is a secret link that opens a guide without logging in, whether
+// or not the guide is public. Links can expire and be revoked.
+type GuideShare struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	GuideID      uuid.UUID  `json:"guide_id" gorm:"type:uuid;not null;index"`
+	Token        string     `json:"token" gorm:"uniqueIndex;not null"`
+	Label        *string    `json:"label"`
+	Permission   string     `json:"permission" gorm:"not null;default:view"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ViewCount    int64      `json:"view_count" gorm:"not null;default:0"`
+	LastViewedAt *time.Time `json:"last_viewed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// MaxShareLabel is the longest share link label accepted
+const MaxShareLabel = 80
+
+// Active reports whether the link still opens its guide
+func (s *GuideShare) Active(now time.Time) bool {
+	return s.RevokedAt == nil && (s.ExpiresAt == nil || now.Before(*s.ExpiresAt))
+}
+
+// CanComment reports whether the link lets its holders comment
+func (s *GuideShare) CanComment() bool {
+	return s.Permission == SharePermissionComment
+}
+
+// GuideComment is a comment left through a share link that allows commenting.
+// Link holders need no account, so comments carry the name they gave.
+type GuideComment struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	GuideID    uuid.UUID  `json:"guide_id" gorm:"type:uuid;not null;index"`
+	ShareID    uuid.UUID  `json:"share_id" gorm:"type:uuid;not null;index"`
+	UserID     *uuid.UUID `json:"user_id" gorm:"type:uuid"`
+	AuthorName string     `json:"author_name" gorm:"not null"`
+	Text       string     `json:"text" gorm:"not null"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Longest comment and commenter name accepted
+const (
+	MaxCommentLength     = 2000
+	MaxCommentAuthorName = 80
+)
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGuideShareActive(t *testing.T) {
+	now := time.Date(2024, time.June, 3, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	tests := []struct {
+		name  string
+		share GuideShare
+		want  bool
+	}{
+		{"no expiry", GuideShare{}, true},
+		{"expires later", GuideShare{ExpiresAt: &future}, true},
+		{"expired", GuideShare{ExpiresAt: &past}, false},
+		{"expires now", GuideShare{ExpiresAt: &now}, false},
+		{"revoked", GuideShare{RevokedAt: &past}, false},
+		{"revoked before expiry", GuideShare{RevokedAt: &past, ExpiresAt: &future}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.share.Active(now); got != tt.want {
+			t.Errorf("%s: Active = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGuideShareCanComment(t *testing.T) {
+	for permission, want := range map[string]bool{
+		SharePermissionView:    false,
+		SharePermissionComment: true,
+		"":                     false,
+		"edit":                 false,
+	} {
+		share := GuideShare{Permission: permission}
+		if got := share.CanComment(); got != want {
+			t.Errorf("CanComment(%q) = %v, want %v", permission, got, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lib/pq"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxTagLength is the longest tag accepted after normalization
+const MaxTagLength = 40
+
+// TagAlias maps a synonym onto a canonical tag, e.g. "cafe-coffee" -> "coffee"
+type TagAlias struct {
+	Alias     string    `json:"alias" gorm:"primaryKey"`
+	Tag       string    `json:"tag" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NormalizeTag folds case, strips accents and joins words with dashes,
+// so "Coffee ", "coffee" and "Café  Coffee" become "coffee" and "cafe-coffee"
+func NormalizeTag(tag string) string {
+	normalized := strings.Join(FoldWords(tag), "-")
+	if r := []rune(normalized); len(r) > MaxTagLength {
+		normalized = strings.TrimRight(string(r[:MaxTagLength]), "-")
+	}
+	return normalized
+}
+
+// FoldWords lowercases text, strips accents and splits it into words of
+// letters and digits
+func FoldWords(text string) []string {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, text)
+	if err != nil {
+		folded = text
+	}
+	return strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// NormalizeTags normalizes every tag and drops empties and duplicates
+func NormalizeTags(tags []string) pq.StringArray {
+	result := make(pq.StringArray, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		n := NormalizeTag(tag)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		result = append(result, n)
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package pdf
+
+import (
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Font is one of the standard PDF fonts, which viewers provide themselves
+type Font int
+
+// Available fonts
+const (
+	Helvetica Font = iota
+	HelveticaBold
+	HelveticaOblique
+)
+
+type fontInfo struct {
+	name string
+	// widths of the printable ASCII characters in 1/1000 em
+	widths [95]int
+	// fallback width for other characters
+	fallback int
+}
+
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// fonts are indexed by Font
+var fonts = []fontInfo{
+	{name: "Helvetica", widths: helveticaWidths, fallback: 556},
+	{name: "Helvetica-Bold", widths: helveticaBoldWidths, fallback: 611},
+	{name: "Helvetica-Oblique", widths: helveticaWidths, fallback: 556},
+}
+
+// TextWidth returns the width of text in points
+func TextWidth(font Font, size float64, text string) float64 {
+	total := 0
+	for _, r := range text {
+		total += charWidth(font, r)
+	}
+	return float64(total) * size / 1000
+}
+
+// charWidth returns the width of a character in 1/1000 em
+func charWidth(font Font, r rune) int {
+	info := fonts[font]
+	if r >= 32 && r < 127 {
+		return info.widths[r-32]
+	}
+	return info.fallback
+}
+
+// Wrap breaks text into lines no wider than width, at spaces where possible.
+// Newlines in the text start new lines.
+func Wrap(font Font, size float64, text string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := ""
+		for _, word := range words {
+			// Split words too long for a line of their own, filling each
+			// line in turn and keeping at least one character per line
+			if TextWidth(font, size, word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				var chunk []rune
+				units := 0
+				for _, r := range word {
+					w := charWidth(font, r)
+					if len(chunk) > 0 && float64(units+w)*size/1000 > width {
+						lines = append(lines, string(chunk))
+						chunk, units = chunk[:0], 0
+					}
+					chunk = append(chunk, r)
+					units += w
+				}
+				word = string(chunk)
+			}
+			switch candidate := line + " " + word; {
+			case line == "":
+				line = word
+			case TextWidth(font, size, candidate) <= width:
+				line = candidate
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// encode converts text to WinAnsiEncoding, replacing characters it can't represent
+func encode(text string) string {
+	var out strings.Builder
+	for _, r := range text {
+		if b, ok := charmap.Windows1252.EncodeRune(r); ok {
+			out.WriteByte(b)
+		} else {
+			out.WriteByte('?')
+		}
+	}
+	return out.String()
+}
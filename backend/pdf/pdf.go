@@ -0,0 +1,199 @@
+// Package pdf writes simple PDF documents: text in the standard Helvetica
+// fonts, lines, rectangles and JPEG images, on any number of pages. Page
+// coordinates are in points from the top-left corner.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Document is a PDF being built
+type Document struct {
+	width, height float64
+	pages         []*Page
+	images        [][]byte
+	imageSizes    []image.Point
+	title         string
+}
+
+// Page is one page of a document
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+	images  []int
+}
+
+// New creates an empty document with pages of the given size in points
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// SetTitle sets the document title shown by viewers
+func (d *Document) SetTitle(title string) {
+	d.title = title
+}
+
+// Width returns the page width in points
+func (d *Document) Width() float64 { return d.width }
+
+// Height returns the page height in points
+func (d *Document) Height() float64 { return d.height }
+
+// AddPage appends a blank page
+func (d *Document) AddPage() *Page {
+	page := &Page{doc: d}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Pages returns the document's pages in order
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// Text draws a line of text with its baseline at y
+func (p *Page) Text(x, y float64, font Font, size float64, text string, c Color) {
+	fmt.Fprintf(&p.content, "BT %s rg /F%d %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		c.operands(), int(font)+1, size, x, p.doc.height-y, escapeString(encode(text)))
+}
+
+// Line draws a straight line
+func (p *Page) Line(x1, y1, x2, y2, width float64, c Color) {
+	fmt.Fprintf(&p.content, "%s RG %.2f w %.2f %.2f m %.2f %.2f l S\n",
+		c.operands(), width, x1, p.doc.height-y1, x2, p.doc.height-y2)
+}
+
+// Rect fills a rectangle whose top-left corner is at x, y
+func (p *Page) Rect(x, y, w, h float64, c Color) {
+	fmt.Fprintf(&p.content, "%s rg %.2f %.2f %.2f %.2f re f\n",
+		c.operands(), x, p.doc.height-y-h, w, h)
+}
+
+// Image draws an image scaled into the box whose top-left corner is at x, y
+func (p *Page) Image(img image.Image, x, y, w, h float64) error {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return err
+	}
+	index := len(p.doc.images)
+	p.doc.images = append(p.doc.images, buf.Bytes())
+	p.doc.imageSizes = append(p.doc.imageSizes, img.Bounds().Size())
+	p.images = append(p.images, index)
+
+	fmt.Fprintf(&p.content, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n",
+		w, h, x, p.doc.height-y-h, index+1)
+	return nil
+}
+
+// Color is an RGB color with components from 0 to 1
+type Color struct{ R, G, B float64 }
+
+// Black is the default text color
+var Black = Color{}
+
+// Gray returns a neutral color, 0 being black and 1 white
+func Gray(level float64) Color { return Color{level, level, level} }
+
+func (c Color) operands() string {
+	return fmt.Sprintf("%.3f %.3f %.3f", c.R, c.G, c.B)
+}
+
+// Write encodes the document
+func (d *Document) Write(w io.Writer) error {
+	var buf bytes.Buffer
+	var offsets []int
+	// Objects are numbered from 1 in the order they are written
+	begin := func() int {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n", len(offsets))
+		return len(offsets)
+	}
+	end := func() { buf.WriteString("endobj\n") }
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: catalog, 2: page tree, 3: info, then fonts, images and pages
+	fontBase := 4
+	imageBase := fontBase + len(fonts)
+	pageBase := imageBase + len(d.images)
+
+	begin()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\n")
+	end()
+
+	begin()
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageBase+2*i)
+	}
+	fmt.Fprintf(&buf, "<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %.2f %.2f] >>\n",
+		strings.Join(kids, " "), len(d.pages), d.width, d.height)
+	end()
+
+	begin()
+	fmt.Fprintf(&buf, "<< /Title (%s) /Producer (MyArea) >>\n", escapeString(encode(d.title)))
+	end()
+
+	for _, font := range fonts {
+		begin()
+		fmt.Fprintf(&buf, "<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>\n", font.name)
+		end()
+	}
+
+	for i, data := range d.images {
+		begin()
+		size := d.imageSizes[i]
+		fmt.Fprintf(&buf, "<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n",
+			size.X, size.Y, len(data))
+		buf.Write(data)
+		buf.WriteString("\nendstream\n")
+		end()
+	}
+
+	var fontRefs strings.Builder
+	for i := range fonts {
+		fmt.Fprintf(&fontRefs, "/F%d %d 0 R ", i+1, fontBase+i)
+	}
+	for i, page := range d.pages {
+		var imageRefs strings.Builder
+		for _, index := range page.images {
+			fmt.Fprintf(&imageRefs, "/Im%d %d 0 R ", index+1, imageBase+index)
+		}
+		begin()
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /Resources << /Font << %s>> /XObject << %s>> >> /Contents %d 0 R >>\n",
+			fontRefs.String(), imageRefs.String(), pageBase+2*i+1)
+		end()
+
+		begin()
+		fmt.Fprintf(&buf, "<< /Length %d >>\nstream\n", page.content.Len())
+		buf.Write(page.content.Bytes())
+		buf.WriteString("endstream\n")
+		end()
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// escapeString escapes a PDF literal string
+func escapeString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", `\r`, "\n", `\n`).Replace(s)
+}
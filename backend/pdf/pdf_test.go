@@ -0,0 +1,144 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteCrossReferences(t *testing.T) {
+	doc := New(A4Width, A4Height)
+	doc.SetTitle("Café (weekend)")
+	page := doc.AddPage()
+	page.Text(40, 60, HelveticaBold, 18, "Day 1", Black)
+	page.Line(40, 70, 200, 70, 0.5, Gray(0.5))
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	img.Set(1, 1, color.RGBA{255, 0, 0, 255})
+	if err := page.Image(img, 40, 80, 100, 75); err != nil {
+		t.Fatal(err)
+	}
+	doc.AddPage().Rect(10, 10, 20, 20, Color{R: 1})
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.Bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+
+	// startxref points at the xref table
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if match == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+
+	// Every entry points at the start of its object
+	var count int
+	lines := strings.Split(string(out[xref:]), "\n")
+	fmt.Sscanf(lines[1], "0 %d", &count)
+	// Catalog, pages, info, 3 fonts, 1 image and 2 objects per page
+	if want := 1 + 3 + len(fonts) + 1 + 2*2; count != want {
+		t.Errorf("xref has %d entries, want %d", count, want)
+	}
+	for i := 1; i < count; i++ {
+		offset, err := strconv.Atoi(lines[2+i][:10])
+		if err != nil {
+			t.Fatalf("bad xref entry %q", lines[2+i])
+		}
+		if header := fmt.Sprintf("%d 0 obj\n", i); !bytes.HasPrefix(out[offset:], []byte(header)) {
+			t.Errorf("object %d: offset %d points at %q", i, offset, out[offset:offset+10])
+		}
+	}
+
+	// Stream lengths match their content
+	streams := regexp.MustCompile(`(?s)/Length (\d+) >>\nstream\n`).FindAllSubmatchIndex(out, -1)
+	if len(streams) != 3 {
+		t.Fatalf("found %d streams, want 3", len(streams))
+	}
+	for _, s := range streams {
+		length, _ := strconv.Atoi(string(out[s[2]:s[3]]))
+		if rest := out[s[1]+length:]; !bytes.HasPrefix(rest, []byte("\nendstream")) && !bytes.HasPrefix(rest, []byte("endstream")) {
+			t.Errorf("stream at %d is not %d bytes long", s[1], length)
+		}
+	}
+
+	// The title is escaped and WinAnsi encoded
+	if !bytes.Contains(out, []byte("/Title (Caf\xe9 \\(weekend\\))")) {
+		t.Error("title not escaped")
+	}
+}
+
+func TestTextEscaping(t *testing.T) {
+	doc := New(100, 100)
+	page := doc.AddPage()
+	page.Text(0, 10, Helvetica, 10, `a (b) c\d`+"\nx → y", Black)
+	got := page.content.String()
+	if !strings.Contains(got, `(a \(b\) c\\d\nx ? y) Tj`) {
+		t.Errorf("content = %q", got)
+	}
+	// y is measured from the top
+	if !strings.Contains(got, "0.00 90.00 Td") {
+		t.Errorf("content = %q", got)
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	if got := TextWidth(Helvetica, 10, "Hi"); got != 9.44 {
+		t.Errorf("Helvetica width = %v, want 9.44", got)
+	}
+	if TextWidth(HelveticaBold, 10, "Hi") <= TextWidth(Helvetica, 10, "Hi") {
+		t.Error("bold should be wider")
+	}
+	if got := TextWidth(Helvetica, 10, "é"); got != 5.56 {
+		t.Errorf("fallback width = %v, want 5.56", got)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	width := TextWidth(Helvetica, 10, "the quick brown")
+	got := Wrap(Helvetica, 10, "the quick brown fox jumps\n\nover", width)
+	want := []string{"the quick brown", "fox jumps", "", "over"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Wrap = %q, want %q", got, want)
+	}
+
+	for _, line := range Wrap(Helvetica, 10, "a supercalifragilisticexpialidocious word", 40) {
+		if TextWidth(Helvetica, 10, line) > 40 {
+			t.Errorf("line %q is wider than 40pt", line)
+		}
+	}
+
+	// Long unbroken text is split in linear time
+	long := strings.Repeat("abcdefghij", 10000)
+	start := time.Now()
+	split := Wrap(Helvetica, 10, long, 200)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("wrapping %d characters took %s", len(long), elapsed)
+	}
+	if strings.Join(split, "") != long {
+		t.Error("splitting lost characters")
+	}
+	for _, line := range split {
+		if TextWidth(Helvetica, 10, line) > 200 {
+			t.Fatalf("line %q is wider than 200pt", line)
+		}
+	}
+
+	// A single character wider than the line still terminates
+	if got := Wrap(Helvetica, 10, "W", 1); len(got) != 1 || got[0] != "W" {
+		t.Errorf("Wrap(narrow) = %q", got)
+	}
+}
@@ -0,0 +1,158 @@
+package routing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultOSRMURL is the public OSRM demo server. It only serves driving
+// routes, so deployments should point ROUTER_URL at their own instance.
+const DefaultOSRMURL = "https://router.project-osrm.org"
+
+// DefaultValhallaURL is a local Valhalla instance
+const DefaultValhallaURL = "http://localhost:8002"
+
+// OSRMRouter talks to an OSRM-compatible /route/v1 API
+type OSRMRouter struct {
+	BaseURL string
+	// Profiles maps modes to OSRM profile names
+	Profiles map[Mode]string
+	Client   *http.Client
+}
+
+// NewOSRMRouter creates a router for the given base URL
+func NewOSRMRouter(baseURL string) *OSRMRouter {
+	if baseURL == "" {
+		baseURL = DefaultOSRMURL
+	}
+	return &OSRMRouter{
+		BaseURL:  strings.TrimRight(baseURL, "/"),
+		Profiles: map[Mode]string{ModeWalk: "foot", ModeBike: "bike", ModeDrive: "driving"},
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Route implements Router
+func (r *OSRMRouter) Route(ctx context.Context, mode Mode, from, to Point) (*Leg, error) {
+	profile, ok := r.Profiles[mode]
+	if !ok {
+		return nil, fmt.Errorf("osrm: unsupported mode %q", mode)
+	}
+	url := fmt.Sprintf("%s/route/v1/%s/%s,%s;%s,%s?overview=false",
+		r.BaseURL, profile,
+		formatCoord(from.Longitude), formatCoord(from.Latitude),
+		formatCoord(to.Longitude), formatCoord(to.Latitude))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Code   string `json:"code"`
+		Routes []struct {
+			Distance float64 `json:"distance"`
+			Duration float64 `json:"duration"`
+		} `json:"routes"`
+	}
+	if err := doJSON(r.Client, req, &body); err != nil {
+		return nil, fmt.Errorf("osrm: %w", err)
+	}
+	if body.Code != "Ok" || len(body.Routes) == 0 {
+		return nil, ErrNoRoute
+	}
+	return &Leg{Mode: mode, DistanceMeters: body.Routes[0].Distance, DurationSeconds: body.Routes[0].Duration}, nil
+}
+
+// ValhallaRouter talks to a Valhalla-compatible /route API
+type ValhallaRouter struct {
+	BaseURL string
+	// Costings maps modes to Valhalla costing models
+	Costings map[Mode]string
+	Client   *http.Client
+}
+
+// NewValhallaRouter creates a router for the given base URL
+func NewValhallaRouter(baseURL string) *ValhallaRouter {
+	if baseURL == "" {
+		baseURL = DefaultValhallaURL
+	}
+	return &ValhallaRouter{
+		BaseURL:  strings.TrimRight(baseURL, "/"),
+		Costings: map[Mode]string{ModeWalk: "pedestrian", ModeBike: "bicycle", ModeDrive: "auto"},
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Route implements Router
+func (r *ValhallaRouter) Route(ctx context.Context, mode Mode, from, to Point) (*Leg, error) {
+	costing, ok := r.Costings[mode]
+	if !ok {
+		return nil, fmt.Errorf("valhalla: unsupported mode %q", mode)
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"locations": []map[string]float64{
+			{"lat": from.Latitude, "lon": from.Longitude},
+			{"lat": to.Latitude, "lon": to.Longitude},
+		},
+		"costing":            costing,
+		"directions_options": map[string]string{"units": "kilometers"},
+		"directions_type":    "none",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.BaseURL+"/route", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var body struct {
+		Trip struct {
+			Status  int `json:"status"`
+			Summary struct {
+				Length float64 `json:"length"`
+				Time   float64 `json:"time"`
+			} `json:"summary"`
+		} `json:"trip"`
+	}
+	if err := doJSON(r.Client, req, &body); err != nil {
+		return nil, fmt.Errorf("valhalla: %w", err)
+	}
+	if body.Trip.Status != 0 {
+		return nil, ErrNoRoute
+	}
+	return &Leg{Mode: mode, DistanceMeters: body.Trip.Summary.Length * 1000, DurationSeconds: body.Trip.Summary.Time}, nil
+}
+
+// doJSON sends a request and decodes a JSON response. Client errors from the
+// engine mean there is no route between the points.
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return ErrNoRoute
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func formatCoord(f float64) string {
+	return strconv.FormatFloat(f, 'f', 6, 64)
+}
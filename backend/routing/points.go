@@ -0,0 +1,25 @@
+package routing
+
+import "myarea-backend/geo"
+
+// Point is a stop's position
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// GreatCircleMatrix returns the pairwise great-circle distances in meters
+func GreatCircleMatrix(points []Point) [][]float64 {
+	dist := make([][]float64, len(points))
+	for i := range points {
+		dist[i] = make([]float64, len(points))
+	}
+	for i := range points {
+		for j := i + 1; j < len(points); j++ {
+			d := geo.DistanceMeters(points[i].Latitude, points[i].Longitude, points[j].Latitude, points[j].Longitude)
+			dist[i][j] = d
+			dist[j][i] = d
+		}
+	}
+	return dist
+}
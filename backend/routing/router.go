@@ -0,0 +1,157 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode is a way of travelling between stops
+type Mode string
+
+// Travel modes
+const (
+	ModeWalk  Mode = "walk"
+	ModeBike  Mode = "bike"
+	ModeDrive Mode = "drive"
+)
+
+// ParseMode reads a travel mode, defaulting to walking
+func ParseMode(s string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(s))) {
+	case "", ModeWalk:
+		return ModeWalk, nil
+	case ModeBike:
+		return ModeBike, nil
+	case ModeDrive:
+		return ModeDrive, nil
+	}
+	return "", fmt.Errorf("unknown travel mode %q", s)
+}
+
+// ErrNoRoute is returned when a routing engine finds no route between two points
+var ErrNoRoute = errors.New("routing: no route found")
+
+// Leg is the travel between two stops. Estimated is set when it comes from
+// the straight-line fallback rather than a routing engine.
+type Leg struct {
+	Mode            Mode    `json:"mode"`
+	DistanceMeters  float64 `json:"distance_meters"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Estimated       bool    `json:"estimated"`
+}
+
+// Router measures travel between two points
+type Router interface {
+	Route(ctx context.Context, mode Mode, from, to Point) (*Leg, error)
+}
+
+// DefaultRouter is the router used by the handlers. It always falls back to
+// straight-line estimates, so it is never nil after SetupRouter.
+var DefaultRouter Router = NewCachedRouter(StraightLineRouter{}, 24*time.Hour)
+
+// SetupRouter configures DefaultRouter from the environment.
+//
+// ROUTER selects the engine: "osrm", "valhalla" or "none" (default, straight-line
+// estimates only). ROUTER_URL is the engine's base URL.
+func SetupRouter() {
+	var engine Router
+	switch strings.ToLower(os.Getenv("ROUTER")) {
+	case "osrm":
+		engine = NewOSRMRouter(os.Getenv("ROUTER_URL"))
+		log.Println("✅ Using OSRM router")
+	case "valhalla":
+		engine = NewValhallaRouter(os.Getenv("ROUTER_URL"))
+		log.Println("✅ Using Valhalla router")
+	default:
+		log.Println("⏭️  No routing engine, using straight-line travel estimates")
+	}
+
+	var router Router = StraightLineRouter{}
+	if engine != nil {
+		router = FallbackRouter{Primary: engine, Fallback: StraightLineRouter{}}
+	}
+	DefaultRouter = NewCachedRouter(router, 24*time.Hour)
+}
+
+// FallbackRouter asks Primary first and uses Fallback when it fails
+type FallbackRouter struct {
+	Primary  Router
+	Fallback Router
+}
+
+// Route implements Router
+func (r FallbackRouter) Route(ctx context.Context, mode Mode, from, to Point) (*Leg, error) {
+	leg, err := r.Primary.Route(ctx, mode, from, to)
+	if err == nil {
+		return leg, nil
+	}
+	if !errors.Is(err, ErrNoRoute) && !errors.Is(err, context.Canceled) {
+		log.Printf("Routing engine failed, falling back to estimate: %v", err)
+	}
+	return r.Fallback.Route(ctx, mode, from, to)
+}
+
+// CachedRouter remembers legs per pair of points and mode. Keys use the
+// coordinates, so a location that moves gets a fresh leg.
+type CachedRouter struct {
+	Router Router
+	TTL    time.Duration
+
+	mu      sync.Mutex
+	entries map[cacheKey]cacheEntry
+}
+
+type cacheKey struct {
+	mode     Mode
+	from, to Point
+}
+
+type cacheEntry struct {
+	leg     Leg
+	expires time.Time
+}
+
+// maxCacheEntries bounds the cache; it is cleared when full
+const maxCacheEntries = 50000
+
+// NewCachedRouter wraps a router with a cache
+func NewCachedRouter(router Router, ttl time.Duration) *CachedRouter {
+	return &CachedRouter{Router: router, TTL: ttl, entries: map[cacheKey]cacheEntry{}}
+}
+
+// Route implements Router
+func (r *CachedRouter) Route(ctx context.Context, mode Mode, from, to Point) (*Leg, error) {
+	key := cacheKey{mode: mode, from: from, to: to}
+
+	r.mu.Lock()
+	entry, ok := r.entries[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		leg := entry.leg
+		return &leg, nil
+	}
+
+	leg, err := r.Router.Route(ctx, mode, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := r.TTL
+	if leg.Estimated {
+		// Retry the engine sooner when it was unavailable
+		ttl = min(ttl, 10*time.Minute)
+	}
+	r.mu.Lock()
+	if len(r.entries) >= maxCacheEntries {
+		r.entries = map[cacheKey]cacheEntry{}
+	}
+	r.entries[key] = cacheEntry{leg: *leg, expires: time.Now().Add(ttl)}
+	r.mu.Unlock()
+	return leg, nil
+}
@@ -0,0 +1,175 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	ferryBuilding = Point{Latitude: 37.7955, Longitude: -122.3937}
+	coitTower     = Point{Latitude: 37.8024, Longitude: -122.4058}
+)
+
+func TestParseMode(t *testing.T) {
+	for input, want := range map[string]Mode{"": ModeWalk, "walk": ModeWalk, " Bike ": ModeBike, "DRIVE": ModeDrive} {
+		if got, err := ParseMode(input); err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v", input, got, err)
+		}
+	}
+	if _, err := ParseMode("teleport"); err == nil {
+		t.Error("ParseMode accepted an unknown mode")
+	}
+}
+
+func TestStraightLineRouter(t *testing.T) {
+	walk, _ := StraightLineRouter{}.Route(context.Background(), ModeWalk, ferryBuilding, coitTower)
+	drive, _ := StraightLineRouter{}.Route(context.Background(), ModeDrive, ferryBuilding, coitTower)
+
+	if !walk.Estimated || walk.Mode != ModeWalk {
+		t.Errorf("walk leg = %+v", walk)
+	}
+	// About 1.3 km as the crow flies, padded for streets
+	if walk.DistanceMeters < 1300 || walk.DistanceMeters > 2000 {
+		t.Errorf("walk distance = %.0f m", walk.DistanceMeters)
+	}
+	if drive.DurationSeconds >= walk.DurationSeconds {
+		t.Errorf("driving (%.0fs) should be faster than walking (%.0fs)", drive.DurationSeconds, walk.DurationSeconds)
+	}
+}
+
+// stubRouter returns a fixed leg or error and counts calls
+type stubRouter struct {
+	leg   *Leg
+	err   error
+	calls int
+}
+
+func (r *stubRouter) Route(ctx context.Context, mode Mode, from, to Point) (*Leg, error) {
+	r.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	leg := *r.leg
+	return &leg, nil
+}
+
+func TestFallbackRouter(t *testing.T) {
+	failing := &stubRouter{err: errors.New("connection refused")}
+	router := FallbackRouter{Primary: failing, Fallback: StraightLineRouter{}}
+	leg, err := router.Route(context.Background(), ModeWalk, ferryBuilding, coitTower)
+	if err != nil || !leg.Estimated {
+		t.Errorf("fallback leg = %+v, %v", leg, err)
+	}
+
+	working := &stubRouter{leg: &Leg{Mode: ModeWalk, DistanceMeters: 1800, DurationSeconds: 1300}}
+	router.Primary = working
+	leg, err = router.Route(context.Background(), ModeWalk, ferryBuilding, coitTower)
+	if err != nil || leg.Estimated || leg.DistanceMeters != 1800 {
+		t.Errorf("engine leg = %+v, %v", leg, err)
+	}
+}
+
+func TestCachedRouter(t *testing.T) {
+	engine := &stubRouter{leg: &Leg{Mode: ModeWalk, DistanceMeters: 1800, DurationSeconds: 1300}}
+	router := NewCachedRouter(engine, 0)
+	router.TTL = 1 << 62
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		leg, err := router.Route(ctx, ModeWalk, ferryBuilding, coitTower)
+		if err != nil || leg.DistanceMeters != 1800 {
+			t.Fatalf("Route = %+v, %v", leg, err)
+		}
+		// Callers get their own copy
+		leg.DistanceMeters = 0
+	}
+	if engine.calls != 1 {
+		t.Errorf("engine called %d times, want 1", engine.calls)
+	}
+
+	// Mode and direction are part of the key
+	router.Route(ctx, ModeBike, ferryBuilding, coitTower)
+	router.Route(ctx, ModeWalk, coitTower, ferryBuilding)
+	if engine.calls != 3 {
+		t.Errorf("engine called %d times, want 3", engine.calls)
+	}
+
+	// Errors are not cached
+	failing := &stubRouter{err: ErrNoRoute}
+	router = NewCachedRouter(failing, 1<<62)
+	router.Route(ctx, ModeWalk, ferryBuilding, coitTower)
+	router.Route(ctx, ModeWalk, ferryBuilding, coitTower)
+	if failing.calls != 2 {
+		t.Errorf("failing engine called %d times, want 2", failing.calls)
+	}
+}
+
+func TestOSRMRouter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/route/v1/foot/-122.393700,37.795500;-122.405800,37.802400":
+			w.Write([]byte(`{"code":"Ok","routes":[{"distance":1834.2,"duration":1320.5}]}`))
+		case "/route/v1/driving/-122.393700,37.795500;-122.405800,37.802400":
+			w.Write([]byte(`{"code":"NoRoute","routes":[]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	router := NewOSRMRouter(server.URL + "/")
+	leg, err := router.Route(context.Background(), ModeWalk, ferryBuilding, coitTower)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if leg.DistanceMeters != 1834.2 || leg.DurationSeconds != 1320.5 || leg.Estimated {
+		t.Errorf("leg = %+v", leg)
+	}
+	if _, err := router.Route(context.Background(), ModeDrive, ferryBuilding, coitTower); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("NoRoute: err = %v", err)
+	}
+	if _, err := router.Route(context.Background(), ModeBike, ferryBuilding, coitTower); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("400 response: err = %v", err)
+	}
+}
+
+func TestValhallaRouter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Costing   string               `json:"costing"`
+			Locations []map[string]float64 `json:"locations"`
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/route" || json.NewDecoder(r.Body).Decode(&body) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if body.Costing == "auto" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if len(body.Locations) != 2 || body.Locations[1]["lat"] != coitTower.Latitude {
+			t.Errorf("locations = %v", body.Locations)
+		}
+		w.Write([]byte(`{"trip":{"status":0,"summary":{"length":1.9,"time":1400}}}`))
+	}))
+	defer server.Close()
+
+	router := NewValhallaRouter(server.URL)
+	leg, err := router.Route(context.Background(), ModeBike, ferryBuilding, coitTower)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if math.Abs(leg.DistanceMeters-1900) > 1e-6 || leg.DurationSeconds != 1400 {
+		t.Errorf("leg = %+v", leg)
+	}
+	_, err = router.Route(context.Background(), ModeDrive, ferryBuilding, coitTower)
+	if err == nil || errors.Is(err, ErrNoRoute) || !strings.Contains(err.Error(), "valhalla") {
+		t.Errorf("server error: err = %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+// Package routing orders and measures the stops of a guide.
+package routing
+
+import (
+	"context"
+	"math"
+)
+
+// exactLimit is the largest number of stops solved exactly; larger tours use
+// nearest-neighbour construction improved with 2-opt
+const exactLimit = 12
+
+// heuristicStarts is the most starting stops the heuristic tries; they are
+// spread evenly over the stops when there are more
+const heuristicStarts = 16
+
+// TourOptions constrains a tour. Start and End are stop indexes, or -1 when free.
+// A closed tour returns to its first stop; End is ignored for closed tours.
+type TourOptions struct {
+	Closed bool
+	Start  int
+	End    int
+}
+
+// SolveTour finds a short order to visit every stop given a symmetric distance
+// matrix. It returns the order as stop indexes and its total length. Once ctx
+// is done, large tours stop improving and the best order so far is returned.
+func SolveTour(ctx context.Context, dist [][]float64, opts TourOptions) ([]int, float64) {
+	n := len(dist)
+	if n == 0 {
+		return []int{}, 0
+	}
+	if opts.Closed {
+		opts.End = -1
+		if opts.Start < 0 {
+			opts.Start = 0
+		}
+	}
+
+	var order []int
+	if n <= exactLimit {
+		order = heldKarp(dist, opts)
+	} else {
+		order = heuristicTour(ctx, dist, opts)
+	}
+	return order, TourLength(dist, order, opts.Closed)
+}
+
+// TourLength sums the legs of an order, including the way back when closed
+func TourLength(dist [][]float64, order []int, closed bool) float64 {
+	total := 0.0
+	for i := 1; i < len(order); i++ {
+		total += dist[order[i-1]][order[i]]
+	}
+	if closed && len(order) > 1 {
+		total += dist[order[len(order)-1]][order[0]]
+	}
+	return total
+}
+
+// heldKarp solves the tour exactly by dynamic programming over subsets
+func heldKarp(dist [][]float64, opts TourOptions) []int {
+	n := len(dist)
+	full := 1<<n - 1
+	cost := make([][]float64, 1<<n)
+	parent := make([][]int8, 1<<n)
+	for mask := range cost {
+		cost[mask] = make([]float64, n)
+		parent[mask] = make([]int8, n)
+		for j := range cost[mask] {
+			cost[mask][j] = math.Inf(1)
+			parent[mask][j] = -1
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		if opts.Start >= 0 && i != opts.Start {
+			continue
+		}
+		if opts.End == i && n > 1 {
+			continue
+		}
+		cost[1<<i][i] = 0
+	}
+
+	for mask := 1; mask <= full; mask++ {
+		for j := 0; j < n; j++ {
+			c := cost[mask][j]
+			if math.IsInf(c, 1) {
+				continue
+			}
+			for k := 0; k < n; k++ {
+				if mask&(1<<k) != 0 {
+					continue
+				}
+				next := mask | 1<<k
+				// A fixed end can only be the last stop
+				if k == opts.End && next != full {
+					continue
+				}
+				if nc := c + dist[j][k]; nc < cost[next][k] {
+					cost[next][k] = nc
+					parent[next][k] = int8(j)
+				}
+			}
+		}
+	}
+
+	best, bestCost := -1, math.Inf(1)
+	for j := 0; j < n; j++ {
+		if opts.End >= 0 && j != opts.End {
+			continue
+		}
+		c := cost[full][j]
+		if opts.Closed {
+			c += dist[j][opts.Start]
+		}
+		if c < bestCost {
+			best, bestCost = j, c
+		}
+	}
+
+	order := make([]int, n)
+	mask := full
+	for i := n - 1; i >= 0; i-- {
+		order[i] = best
+		prev := int(parent[mask][best])
+		mask &^= 1 << best
+		best = prev
+	}
+	return order
+}
+
+// heuristicTour builds a tour with nearest neighbour from up to
+// heuristicStarts starts and keeps the shortest after 2-opt
+func heuristicTour(ctx context.Context, dist [][]float64, opts TourOptions) []int {
+	n := len(dist)
+	starts := []int{opts.Start}
+	if opts.Start < 0 {
+		starts = starts[:0]
+		for i := 0; i < n; i++ {
+			if i != opts.End {
+				starts = append(starts, i)
+			}
+		}
+		if len(starts) > heuristicStarts {
+			spread := make([]int, heuristicStarts)
+			for i := range spread {
+				spread[i] = starts[i*len(starts)/heuristicStarts]
+			}
+			starts = spread
+		}
+	}
+
+	var best []int
+	bestCost := math.Inf(1)
+	for _, start := range starts {
+		// Always finish one tour so there is an answer
+		if best != nil && ctx.Err() != nil {
+			break
+		}
+		order := nearestNeighbour(dist, start, opts.End)
+		twoOpt(ctx, dist, order, opts)
+		if c := TourLength(dist, order, opts.Closed); c < bestCost {
+			best, bestCost = order, c
+		}
+	}
+	return best
+}
+
+// nearestNeighbour greedily visits the closest unvisited stop, leaving end for last
+func nearestNeighbour(dist [][]float64, start, end int) []int {
+	n := len(dist)
+	visited := make([]bool, n)
+	order := make([]int, 0, n)
+	current := start
+	visited[current] = true
+	order = append(order, current)
+
+	for len(order) < n {
+		next, nextDist := -1, math.Inf(1)
+		for k := 0; k < n; k++ {
+			if visited[k] || (k == end && len(order) < n-1) {
+				continue
+			}
+			if dist[current][k] < nextDist {
+				next, nextDist = k, dist[current][k]
+			}
+		}
+		visited[next] = true
+		order = append(order, next)
+		current = next
+	}
+	return order
+}
+
+// twoOpt reverses segments of the order while that shortens it, keeping any
+// fixed first and last stops in place. It stops early once ctx is done.
+func twoOpt(ctx context.Context, dist [][]float64, order []int, opts TourOptions) {
+	n := len(order)
+	first := 0
+	if opts.Start >= 0 || opts.Closed {
+		first = 1
+	}
+	last := n - 1
+	if opts.End >= 0 {
+		last = n - 2
+	}
+
+	// edge returns the length of the leg from position a to position b,
+	// treating positions past either end of an open tour as free
+	edge := func(a, b int) float64 {
+		if a < 0 || b < 0 {
+			return 0
+		}
+		if b >= n {
+			if !opts.Closed {
+				return 0
+			}
+			b = 0
+		}
+		return dist[order[a]][order[b]]
+	}
+
+	for improved, rounds := true, 0; improved && rounds < 100 && ctx.Err() == nil; rounds++ {
+		improved = false
+		for i := first; i < last; i++ {
+			for k := i + 1; k <= last; k++ {
+				delta := edge(i-1, k) + edge(i, k+1) - edge(i-1, i) - edge(k, k+1)
+				if delta < -1e-9 {
+					for a, b := i, k; a < b; a, b = a+1, b-1 {
+						order[a], order[b] = order[b], order[a]
+					}
+					improved = true
+				}
+			}
+		}
+	}
+}
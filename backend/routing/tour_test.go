@@ -0,0 +1,179 @@
+package routing
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// randomMatrix places n stops on a plane and returns their distances
+func randomMatrix(rng *rand.Rand, n int) [][]float64 {
+	xs, ys := make([]float64, n), make([]float64, n)
+	for i := range xs {
+		xs[i], ys[i] = rng.Float64()*1000, rng.Float64()*1000
+	}
+	dist := make([][]float64, n)
+	for i := range dist {
+		dist[i] = make([]float64, n)
+		for j := range dist[i] {
+			dist[i][j] = math.Hypot(xs[i]-xs[j], ys[i]-ys[j])
+		}
+	}
+	return dist
+}
+
+// bruteForce returns the length of the shortest order meeting opts
+func bruteForce(dist [][]float64, opts TourOptions) float64 {
+	n := len(dist)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	best := math.Inf(1)
+	var permute func(k int)
+	permute = func(k int) {
+		if k == n {
+			if valid(order, opts) {
+				best = math.Min(best, TourLength(dist, order, opts.Closed))
+			}
+			return
+		}
+		for i := k; i < n; i++ {
+			order[k], order[i] = order[i], order[k]
+			permute(k + 1)
+			order[k], order[i] = order[i], order[k]
+		}
+	}
+	permute(0)
+	return best
+}
+
+func valid(order []int, opts TourOptions) bool {
+	if opts.Start >= 0 && order[0] != opts.Start {
+		return false
+	}
+	if !opts.Closed && opts.End >= 0 && order[len(order)-1] != opts.End {
+		return false
+	}
+	return true
+}
+
+// checkOrder fails unless order visits every stop once and meets opts
+func checkOrder(t *testing.T, n int, order []int, opts TourOptions) {
+	t.Helper()
+	if len(order) != n {
+		t.Fatalf("order %v has %d stops, want %d", order, len(order), n)
+	}
+	seen := make([]bool, n)
+	for _, i := range order {
+		if i < 0 || i >= n || seen[i] {
+			t.Fatalf("order %v is not a permutation", order)
+		}
+		seen[i] = true
+	}
+	if !valid(order, opts) {
+		t.Fatalf("order %v does not meet %+v", order, opts)
+	}
+}
+
+func TestSolveTourMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, opts := range []TourOptions{
+		{Start: -1, End: -1},
+		{Start: 2, End: -1},
+		{Start: -1, End: 3},
+		{Start: 0, End: 4},
+		{Closed: true, Start: -1, End: -1},
+		{Closed: true, Start: 3, End: 1},
+	} {
+		for n := 5; n <= 7; n++ {
+			dist := randomMatrix(rng, n)
+			order, length := SolveTour(context.Background(), dist, opts)
+			checkOrder(t, n, order, opts)
+			if got := TourLength(dist, order, opts.Closed); math.Abs(got-length) > 1e-9 {
+				t.Errorf("%+v: reported length %.3f, order measures %.3f", opts, length, got)
+			}
+			if want := bruteForce(dist, opts); length > want+1e-9 {
+				t.Errorf("%+v n=%d: length %.3f, optimum %.3f", opts, n, length, want)
+			}
+		}
+	}
+}
+
+func TestSolveTourHeuristic(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	n := exactLimit + 8
+	dist := randomMatrix(rng, n)
+	for _, opts := range []TourOptions{
+		{Start: -1, End: -1},
+		{Start: 4, End: 9},
+		{Closed: true, Start: 5, End: -1},
+	} {
+		order, length := SolveTour(context.Background(), dist, opts)
+		checkOrder(t, n, order, opts)
+
+		// Never worse than the plain nearest-neighbour tour it starts from
+		start := opts.Start
+		if start < 0 {
+			start = 0
+		}
+		greedy := TourLength(dist, nearestNeighbour(dist, start, opts.End), opts.Closed)
+		if length > greedy+1e-9 {
+			t.Errorf("%+v: length %.1f, nearest neighbour alone %.1f", opts, length, greedy)
+		}
+	}
+}
+
+func TestSolveTourSmall(t *testing.T) {
+	if order, length := SolveTour(context.Background(), nil, TourOptions{Start: -1, End: -1}); len(order) != 0 || length != 0 {
+		t.Errorf("empty tour = %v, %v", order, length)
+	}
+	one := [][]float64{{0}}
+	if order, length := SolveTour(context.Background(), one, TourOptions{Closed: true, Start: -1, End: -1}); len(order) != 1 || length != 0 {
+		t.Errorf("single stop = %v, %v", order, length)
+	}
+	// Stops on a line are visited end to end
+	line := [][]float64{
+		{0, 2, 1},
+		{2, 0, 1},
+		{1, 1, 0},
+	}
+	order, length := SolveTour(context.Background(), line, TourOptions{Start: 0, End: -1})
+	if length != 2 || order[0] != 0 || order[2] != 1 {
+		t.Errorf("line tour = %v, %v", order, length)
+	}
+}
+
+func TestTourLength(t *testing.T) {
+	dist := [][]float64{
+		{0, 1, 5},
+		{1, 0, 2},
+		{5, 2, 0},
+	}
+	if got := TourLength(dist, []int{0, 1, 2}, false); got != 3 {
+		t.Errorf("open length = %v, want 3", got)
+	}
+	if got := TourLength(dist, []int{0, 1, 2}, true); got != 8 {
+		t.Errorf("closed length = %v, want 8", got)
+	}
+	if got := TourLength(dist, []int{1}, true); got != 0 {
+		t.Errorf("single stop length = %v, want 0", got)
+	}
+}
+
+func TestSolveTourStopsAtDeadline(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	n := 400
+	dist := randomMatrix(rng, n)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	order, _ := SolveTour(ctx, dist, TourOptions{Start: -1, End: -1})
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("solving after the deadline took %s", elapsed)
+	}
+	checkOrder(t, n, order, TourOptions{Start: -1, End: -1})
+}
@@ -0,0 +1,50 @@
+package staticmap
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+)
+
+// digitGlyphs is a 3x5 bitmap font for marker numbers, one row per string
+var digitGlyphs = [10][5]string{
+	{"###", "#.#", "#.#", "#.#", "###"},
+	{".#.", "##.", ".#.", ".#.", "###"},
+	{"###", "..#", "###", "#..", "###"},
+	{"###", "..#", ".##", "..#", "###"},
+	{"#.#", "#.#", "###", "..#", "..#"},
+	{"###", "#..", "###", "..#", "###"},
+	{"###", "#..", "###", "#.#", "###"},
+	{"###", "..#", ".#.", ".#.", ".#."},
+	{"###", "#.#", "###", "#.#", "###"},
+	{"###", "#.#", "###", "..#", "###"},
+}
+
+// drawNumber draws n centred on a point, at double size when it fits a marker
+func drawNumber(img *image.RGBA, center image.Point, n int, c color.RGBA) {
+	text := strconv.Itoa(n)
+	scale := 2
+	if len(text) > 2 {
+		scale = 1
+	}
+	width := (len(text)*4 - 1) * scale
+	height := 5 * scale
+	left := center.X - width/2
+	top := center.Y - height/2
+
+	for i, r := range text {
+		glyph := digitGlyphs[r-'0']
+		for row, line := range glyph {
+			for col, bit := range line {
+				if bit != '#' {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						img.SetRGBA(left+(i*4+col)*scale+dx, top+row*scale+dy, c)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package staticmap draws simple overview maps of numbered stops without any
+// map tiles: a Web Mercator projection of the points on a plain background
+// with a graticule, the route between them in order, and numbered markers.
+package staticmap
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Point is a stop to draw, numbered from 1 in the order given
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Options controls the rendered map
+type Options struct {
+	Width  int
+	Height int
+	// Route joins the points in order
+	Route bool
+}
+
+var (
+	backgroundColor = color.RGBA{0xee, 0xf2, 0xf5, 0xff}
+	gridColor       = color.RGBA{0xd5, 0xdc, 0xe3, 0xff}
+	routeColor      = color.RGBA{0x3b, 0x82, 0xf6, 0xff}
+	markerColor     = color.RGBA{0xdc, 0x26, 0x26, 0xff}
+	outlineColor    = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	frameColor      = color.RGBA{0x9c, 0xa3, 0xaf, 0xff}
+)
+
+const (
+	padding      = 36
+	markerRadius = 11
+	// minSpan is the smallest area shown, in projected units (about 1 km)
+	minSpan = 1.0 / 40000
+)
+
+// Render draws the points on a new image
+func Render(points []Point, opts Options) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{backgroundColor}, image.Point{}, draw.Src)
+
+	if len(points) > 0 {
+		proj := fit(points, opts.Width, opts.Height)
+		drawGraticule(img, proj)
+
+		pixels := make([]image.Point, len(points))
+		for i, p := range points {
+			pixels[i] = proj.pixel(p.Latitude, p.Longitude)
+		}
+		if opts.Route {
+			for i := 1; i < len(pixels); i++ {
+				drawLine(img, pixels[i-1], pixels[i], 2, routeColor)
+			}
+		}
+		// Draw the first stop last so it stays on top where markers overlap
+		for i := len(pixels) - 1; i >= 0; i-- {
+			drawMarker(img, pixels[i], i+1)
+		}
+	}
+
+	b := img.Bounds()
+	drawLine(img, image.Pt(0, 0), image.Pt(b.Dx()-1, 0), 0, frameColor)
+	drawLine(img, image.Pt(0, b.Dy()-1), image.Pt(b.Dx()-1, b.Dy()-1), 0, frameColor)
+	drawLine(img, image.Pt(0, 0), image.Pt(0, b.Dy()-1), 0, frameColor)
+	drawLine(img, image.Pt(b.Dx()-1, 0), image.Pt(b.Dx()-1, b.Dy()-1), 0, frameColor)
+	return img
+}
+
+// projection maps Web Mercator coordinates, in [0, 1] across the world, to pixels
+type projection struct {
+	minX, minY float64
+	scale      float64
+	offX, offY float64
+	width      int
+	height     int
+}
+
+func mercator(lat, lng float64) (float64, float64) {
+	lat = math.Max(-85, math.Min(85, lat))
+	x := (lng + 180) / 360
+	rad := lat * math.Pi / 180
+	y := (1 - math.Log(math.Tan(rad)+1/math.Cos(rad))/math.Pi) / 2
+	return x, y
+}
+
+// fit chooses a projection that shows every point with some padding
+func fit(points []Point, width, height int) projection {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		x, y := mercator(p.Latitude, p.Longitude)
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	if span := maxX - minX; span < minSpan {
+		minX -= (minSpan - span) / 2
+		maxX = minX + minSpan
+	}
+	if span := maxY - minY; span < minSpan {
+		minY -= (minSpan - span) / 2
+		maxY = minY + minSpan
+	}
+
+	innerW := float64(width - 2*padding)
+	innerH := float64(height - 2*padding)
+	scale := math.Min(innerW/(maxX-minX), innerH/(maxY-minY))
+	return projection{
+		minX:   minX,
+		minY:   minY,
+		scale:  scale,
+		offX:   padding + (innerW-(maxX-minX)*scale)/2,
+		offY:   padding + (innerH-(maxY-minY)*scale)/2,
+		width:  width,
+		height: height,
+	}
+}
+
+func (p projection) pixel(lat, lng float64) image.Point {
+	x, y := mercator(lat, lng)
+	return image.Pt(int(math.Round(p.offX+(x-p.minX)*p.scale)), int(math.Round(p.offY+(y-p.minY)*p.scale)))
+}
+
+// unproject returns the coordinates at a pixel
+func (p projection) unproject(px, py float64) (float64, float64) {
+	x := (px-p.offX)/p.scale + p.minX
+	y := (py-p.offY)/p.scale + p.minY
+	lng := x*360 - 180
+	lat := math.Atan(math.Sinh(math.Pi*(1-2*y))) * 180 / math.Pi
+	return lat, lng
+}
+
+// drawGraticule draws lines of latitude and longitude at a round interval
+// that gives a handful of lines across the map
+func drawGraticule(img *image.RGBA, proj projection) {
+	north, west := proj.unproject(0, 0)
+	south, east := proj.unproject(float64(proj.width), float64(proj.height))
+	step := niceStep(math.Max(east-west, north-south) / 6)
+
+	for lng := math.Ceil(west/step) * step; lng <= east; lng += step {
+		x := proj.pixel(north, lng).X
+		drawLine(img, image.Pt(x, 0), image.Pt(x, proj.height-1), 0, gridColor)
+	}
+	for lat := math.Ceil(south/step) * step; lat <= north; lat += step {
+		y := proj.pixel(lat, west).Y
+		drawLine(img, image.Pt(0, y), image.Pt(proj.width-1, y), 0, gridColor)
+	}
+}
+
+// niceStep rounds a degree interval to 1, 2 or 5 times a power of ten
+func niceStep(raw float64) float64 {
+	if raw <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch normalized := raw / magnitude; {
+	case normalized < 1.5:
+		return magnitude
+	case normalized < 3.5:
+		return 2 * magnitude
+	case normalized < 7.5:
+		return 5 * magnitude
+	}
+	return 10 * magnitude
+}
+
+// drawLine draws a line with Bresenham's algorithm, stamping a disc of the
+// given radius at each step for thick lines
+func drawLine(img *image.RGBA, a, b image.Point, radius int, c color.RGBA) {
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+	err := dx + dy
+	x, y := a.X, a.Y
+	for {
+		if radius == 0 {
+			img.SetRGBA(x, y, c)
+		} else {
+			fillCircle(img, image.Pt(x, y), radius, c)
+		}
+		if x == b.X && y == b.Y {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y += sy
+		}
+	}
+}
+
+func fillCircle(img *image.RGBA, center image.Point, radius int, c color.RGBA) {
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				img.SetRGBA(center.X+x, center.Y+y, c)
+			}
+		}
+	}
+}
+
+// drawMarker draws a numbered pin
+func drawMarker(img *image.RGBA, at image.Point, number int) {
+	fillCircle(img, at, markerRadius+2, outlineColor)
+	fillCircle(img, at, markerRadius, markerColor)
+	drawNumber(img, at, number, outlineColor)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,81 @@
+package staticmap
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+var stops = []Point{
+	{Latitude: 37.8199, Longitude: -122.4783},
+	{Latitude: 37.7596, Longitude: -122.4269},
+	{Latitude: 37.7955, Longitude: -122.3933},
+}
+
+func TestFitKeepsPointsInside(t *testing.T) {
+	proj := fit(stops, 400, 300)
+	for _, p := range stops {
+		px := proj.pixel(p.Latitude, p.Longitude)
+		if px.X < padding || px.X > 400-padding || px.Y < padding || px.Y > 300-padding {
+			t.Errorf("%+v drawn at %v, outside the padded area", p, px)
+		}
+	}
+	// North is up and east is right
+	golden, dolores := proj.pixel(stops[0].Latitude, stops[0].Longitude), proj.pixel(stops[1].Latitude, stops[1].Longitude)
+	if golden.Y >= dolores.Y || golden.X >= dolores.X {
+		t.Errorf("Golden Gate %v should be north-west of Dolores Park %v", golden, dolores)
+	}
+}
+
+func TestUnprojectInvertsPixel(t *testing.T) {
+	proj := fit(stops, 400, 300)
+	for _, p := range stops {
+		x, y := mercator(p.Latitude, p.Longitude)
+		lat, lng := proj.unproject(proj.offX+(x-proj.minX)*proj.scale, proj.offY+(y-proj.minY)*proj.scale)
+		if math.Abs(lat-p.Latitude) > 1e-9 || math.Abs(lng-p.Longitude) > 1e-9 {
+			t.Errorf("round trip of %+v = %v, %v", p, lat, lng)
+		}
+	}
+}
+
+func TestFitSinglePoint(t *testing.T) {
+	proj := fit(stops[:1], 400, 300)
+	if math.IsInf(proj.scale, 0) || math.IsNaN(proj.scale) {
+		t.Fatalf("scale = %v", proj.scale)
+	}
+	if px := proj.pixel(stops[0].Latitude, stops[0].Longitude); px != image.Pt(200, 150) {
+		t.Errorf("single point drawn at %v, want the centre", px)
+	}
+}
+
+func TestNiceStep(t *testing.T) {
+	for raw, want := range map[float64]float64{0: 1, 0.012: 0.01, 0.025: 0.02, 0.06: 0.05, 0.09: 0.1, 3: 2} {
+		if got := niceStep(raw); math.Abs(got-want) > 1e-12 {
+			t.Errorf("niceStep(%v) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	img := Render(stops, Options{Width: 400, Height: 300, Route: true})
+	if img.Bounds() != image.Rect(0, 0, 400, 300) {
+		t.Fatalf("bounds = %v", img.Bounds())
+	}
+	proj := fit(stops, 400, 300)
+	for _, p := range stops {
+		px := proj.pixel(p.Latitude, p.Longitude)
+		// Just inside the marker, clear of the digits
+		if got := img.RGBAAt(px.X, px.Y+markerRadius-1); got != markerColor {
+			t.Errorf("no marker at %v: %v", px, got)
+		}
+	}
+	if got := img.RGBAAt(0, 0); got != frameColor {
+		t.Errorf("corner = %v, want the frame", got)
+	}
+
+	// No points still gives a blank framed map
+	empty := Render(nil, Options{Width: 50, Height: 40})
+	if got := empty.RGBAAt(25, 20); got != backgroundColor {
+		t.Errorf("empty map centre = %v", got)
+	}
+}
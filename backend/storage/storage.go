@@ -0,0 +1,78 @@
+// Package storage stores uploaded files behind a BlobStore interface.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// ErrNotFound is returned when a blob does not exist
+var ErrNotFound = errors.New("storage: blob not found")
+
+// BlobStore saves and removes files addressed by key
+type BlobStore interface {
+	// Put stores the contents of r under key and returns its public URL
+	Put(ctx context.Context, key, contentType string, r io.Reader, size int64) (string, error)
+	// Delete removes the blob stored under key
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of key
+	URL(key string) string
+}
+
+// Default is the blob store used by the handlers
+var Default BlobStore
+
+// Setup configures Default from the environment.
+//
+// STORAGE_DRIVER selects the implementation: "local" (default) or "s3".
+func Setup() {
+	switch strings.ToLower(os.Getenv("STORAGE_DRIVER")) {
+	case "s3":
+		store, err := NewS3Store(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") != "false",
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure S3 storage: %v", err)
+		}
+		Default = store
+		log.Println("✅ Using S3 storage")
+	default:
+		dir := os.Getenv("UPLOAD_DIR")
+		if dir == "" {
+			dir = "./uploads"
+		}
+		baseURL := os.Getenv("UPLOAD_BASE_URL")
+		if baseURL == "" {
+			baseURL = "/uploads"
+		}
+		store, err := NewLocalStore(dir, baseURL)
+		if err != nil {
+			log.Fatalf("Failed to configure local storage: %v", err)
+		}
+		Default = store
+		log.Printf("✅ Using local storage in %s", dir)
+	}
+}
+
+// validKey rejects keys that could escape the storage root
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}